@@ -0,0 +1,205 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/biter777/countries"
+	"github.com/drksbr/lg2/pkg/parser"
+)
+
+// Thresholds of the anycast heuristics. Paths are counted in collapsed ASes
+// from the peer's own AS to the origin, both included.
+const (
+	AnycastShortPath      = 3   // Caminho "curto": peer, um upstream e a origem
+	AnycastShortShare     = 0.8 // Fração dos peers com caminho curto
+	AnycastUpstreams      = 4   // Upstreams distintos da origem
+	AnycastCountries      = 3   // Países distintos desses upstreams
+	AnycastRegions        = 3   // Regiões com peers para comparar
+	AnycastDominantOrigin = 0.9 // Fração dos peers com a mesma origem
+	anycastRegionSpread   = 1.0 // Diferença máxima entre medianas regionais
+)
+
+// Anycast confidence levels.
+const (
+	ConfidenceNone   = "none"
+	ConfidenceLow    = "low"
+	ConfidenceMedium = "medium"
+	ConfidenceHigh   = "high"
+)
+
+// AnycastSignal is one piece of evidence for or against anycast.
+type AnycastSignal struct {
+	Signal string `json:"signal"`
+	Met    bool   `json:"met"`
+	Weight int    `json:"weight"`
+	Detail string `json:"detail"`
+}
+
+// AnycastEntry is how the peers of one region reach the origin.
+type AnycastEntry struct {
+	Region       string          `json:"region"`
+	Peers        int             `json:"peers"`
+	MedianLength float64         `json:"median_length"`
+	Upstreams    []UpstreamShare `json:"upstreams,omitempty"`
+}
+
+// Anycast is the estimate of whether the prefix is anycast, with the
+// evidence behind it and the apparent entry points per region.
+type Anycast struct {
+	Origin     int             `json:"origin"`
+	Peers      int             `json:"peers"`
+	Score      int             `json:"score"`
+	MaxScore   int             `json:"max_score"`
+	Confidence string          `json:"confidence"`
+	Evidence   []AnycastSignal `json:"evidence"`
+	Entries    []AnycastEntry  `json:"entries,omitempty"`
+}
+
+// Likely reports whether the evidence points to anycast.
+func (a Anycast) Likely() bool {
+	return a.Confidence == ConfidenceMedium || a.Confidence == ConfidenceHigh
+}
+
+// DetectAnycast estimates whether the prefix is anycast. An anycast prefix
+// has one origin reached through many upstreams in different countries,
+// with short paths from everywhere, since every region has a nearby
+// instance. Regions come from the peers' countries (--nodes), or from the
+// registration country of the peer's own AS.
+func DetectAnycast(peers []parser.Peer) Anycast {
+	var result Anycast
+	var routed []parser.Peer // Peers com caminho utilizável
+	var lengths []int
+	for i := range peers {
+		if hops, _ := OriginHops(&peers[i]); len(hops) > 0 {
+			routed = append(routed, peers[i])
+			lengths = append(lengths, len(hops))
+		}
+	}
+	result.Peers = len(routed)
+	if result.Peers == 0 {
+		result.Confidence = ConfidenceNone
+		return result
+	}
+	add := func(signal string, met bool, weight int, detail string) {
+		result.Evidence = append(result.Evidence, AnycastSignal{Signal: signal, Met: met, Weight: weight, Detail: detail})
+		result.MaxScore += weight
+		if met {
+			result.Score += weight
+		}
+	}
+
+	// A single dominant origin; MOAS is a different thing
+	origins := Origins(peers)
+	if len(origins) > 0 {
+		result.Origin = origins[0].Origin
+	}
+	dominant := len(origins) > 0 && origins[0].HasOrigin && float64(origins[0].Peers) >= AnycastDominantOrigin*float64(len(peers))
+	add("single origin", dominant, 0, fmt.Sprintf("%d origin(s), AS%d seen by %d of %d peers", len(origins), result.Origin, originPeers(origins), len(peers)))
+
+	// Many upstreams, in many countries
+	dist := DistributeUpstreams(peers, 1)
+	add("upstream diversity", len(dist.Upstreams) >= AnycastUpstreams, 2,
+		fmt.Sprintf("%d distinct upstreams of the origin (%d or more expected)", len(dist.Upstreams), AnycastUpstreams))
+	known := 0
+	for _, country := range dist.Countries {
+		if country.Country != "??" {
+			known++
+		}
+	}
+	add("upstream countries", known >= AnycastCountries, 1,
+		fmt.Sprintf("upstreams registered in %d countries (%d or more expected)", known, AnycastCountries))
+
+	// Short paths everywhere
+	short := 0
+	for _, length := range lengths {
+		if length <= AnycastShortPath {
+			short++
+		}
+	}
+	shortShare := float64(short) / float64(result.Peers)
+	add("short paths", shortShare >= AnycastShortShare, 2,
+		fmt.Sprintf("%.0f%% of the peers have paths of %d ASes or fewer, their own AS and the origin included", 100*shortShare, AnycastShortPath))
+
+	// Per-region entry points and path lengths
+	byRegion := map[string][]parser.Peer{}
+	regionLengths := map[string][]int{}
+	for i := range routed {
+		if region := Region(PeerCountry(&routed[i])); region != "" {
+			byRegion[region] = append(byRegion[region], routed[i])
+			regionLengths[region] = append(regionLengths[region], lengths[i])
+		}
+	}
+	var medians []float64
+	dominantUpstreams := map[int]bool{}
+	for region, members := range byRegion {
+		entry := AnycastEntry{
+			Region:       region,
+			Peers:        len(members),
+			MedianLength: median(regionLengths[region]),
+			Upstreams:    DistributeUpstreams(members, 1).Upstreams,
+		}
+		result.Entries = append(result.Entries, entry)
+		medians = append(medians, entry.MedianLength)
+		if len(entry.Upstreams) > 0 {
+			dominantUpstreams[entry.Upstreams[0].ASN] = true
+		}
+	}
+	sort.Slice(result.Entries, func(i, j int) bool {
+		if result.Entries[i].Peers != result.Entries[j].Peers {
+			return result.Entries[i].Peers > result.Entries[j].Peers
+		}
+		return result.Entries[i].Region < result.Entries[j].Region
+	})
+
+	if len(byRegion) >= AnycastRegions {
+		spread := 0.0
+		if len(medians) > 0 {
+			lowest, highest := medians[0], medians[0]
+			for _, m := range medians {
+				lowest, highest = min(lowest, m), max(highest, m)
+			}
+			spread = highest - lowest
+		}
+		add("uniform length across regions", spread <= anycastRegionSpread, 1,
+			fmt.Sprintf("regional median path lengths differ by %.1f across %d regions", spread, len(byRegion)))
+		add("regional entry points", len(dominantUpstreams) >= AnycastRegions, 2,
+			fmt.Sprintf("%d different upstreams are the most used in %d regions (%d or more expected)", len(dominantUpstreams), len(byRegion), AnycastRegions))
+	} else {
+		add("regions", false, 0, fmt.Sprintf("peers in %d region(s); load --nodes to compare regions", len(byRegion)))
+	}
+
+	switch {
+	case !dominant || result.Score == 0:
+		result.Confidence = ConfidenceNone
+	case result.Score*3 >= result.MaxScore*2:
+		result.Confidence = ConfidenceHigh
+	case result.Score*2 >= result.MaxScore:
+		result.Confidence = ConfidenceMedium
+	default:
+		result.Confidence = ConfidenceLow
+	}
+	return result
+}
+
+// Region returns the continent of an ISO country code, or "".
+func Region(country string) string {
+	country = strings.TrimSpace(country)
+	if country == "" {
+		return ""
+	}
+	region := countries.ByName(country).Region()
+	if region == countries.RegionUnknown || region == countries.RegionNone {
+		return ""
+	}
+	return region.String()
+}
+
+// originPeers returns the peer count of the most common origin.
+func originPeers(origins []OriginCount) int {
+	if len(origins) == 0 {
+		return 0
+	}
+	return origins[0].Peers
+}
@@ -0,0 +1,213 @@
+package analysis
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/drksbr/lg2/pkg/parser"
+)
+
+// ConsensusShare is the share of peers a suffix must be seen by to be part
+// of the consensus suffix.
+const ConsensusShare = 0.5
+
+// Diff operations of a path against the consensus path.
+const (
+	DiffSame    = "="
+	DiffAdded   = "+" // ASN no caminho do peer mas não no consenso
+	DiffMissing = "-" // ASN do consenso ausente no caminho do peer
+)
+
+// DiffOp is one ASN of a path diff.
+type DiffOp struct {
+	ASN int    `json:"asn"`
+	Op  string `json:"op"`
+}
+
+// PathDiff is a path compared with the consensus path, in path order.
+type PathDiff []DiffOp
+
+// Same reports whether the path equals the consensus path.
+func (d PathDiff) Same() bool {
+	for _, op := range d {
+		if op.Op != DiffSame {
+			return false
+		}
+	}
+	return true
+}
+
+// String renders the diff as "+3356 -174 1299 64500".
+func (d PathDiff) String() string {
+	parts := make([]string, len(d))
+	for i, op := range d {
+		parts[i] = strconv.Itoa(op.ASN)
+		if op.Op != DiffSame {
+			parts[i] = op.Op + parts[i]
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// MarshalText encodes the diff as its string form.
+func (d PathDiff) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// ConsensusOutlier is a peer whose path does not end with the consensus
+// suffix. Diff starts at the peer's neighbour (see Consensus.Diff).
+type ConsensusOutlier struct {
+	Peer string   `json:"peer"`
+	Diff PathDiff `json:"diff"`
+}
+
+// Consensus is what most peers agree on. Paths are compared collapsed, so
+// prepending does not make two routes different, and the most common path
+// starts at the peers' neighbours, since every peer starts with its own AS.
+type Consensus struct {
+	Peers       int                `json:"peers"` // Peers com caminho utilizável
+	Path        []int              `json:"path"`  // Caminho mais comum, a partir do vizinho do peer
+	PathPeers   int                `json:"path_peers"`
+	Suffix      []int              `json:"suffix"` // Maior sufixo visto por ao menos ConsensusShare dos peers
+	SuffixPeers int                `json:"suffix_peers"`
+	Outliers    []ConsensusOutlier `json:"outliers,omitempty"`
+}
+
+// FindConsensus computes the most common path, the longest suffix toward
+// the origin shared by at least ConsensusShare of the peers, and the peers
+// that do not follow that suffix.
+func FindConsensus(peers []parser.Peer) Consensus {
+	var consensus Consensus
+	paths := make([][]int, 0, len(peers))
+	names := make([]string, 0, len(peers))
+	counts := map[string]int{}
+	for i := range peers {
+		path, _ := CollapsedPath(&peers[i])
+		if len(path) == 0 {
+			continue
+		}
+		paths = append(paths, path)
+		names = append(names, peers[i].PeerName)
+		counts[pathKey(fromNeighbour(path))]++
+	}
+	consensus.Peers = len(paths)
+	if consensus.Peers == 0 {
+		return consensus
+	}
+
+	// Most common path; ties go to the shorter path, then the first seen
+	for _, path := range paths {
+		route := fromNeighbour(path)
+		n := counts[pathKey(route)]
+		if n > consensus.PathPeers || (n == consensus.PathPeers && len(route) < len(consensus.Path)) {
+			consensus.Path, consensus.PathPeers = route, n
+		}
+	}
+
+	// Grow the suffix one ASN at a time while enough peers agree
+	needed := ConsensusShare * float64(consensus.Peers)
+	for k := 1; ; k++ {
+		next := map[int]int{}
+		for _, path := range paths {
+			if len(path) >= k && hasSuffix(path, consensus.Suffix) {
+				next[path[len(path)-k]]++
+			}
+		}
+		asn, n := 0, 0
+		for candidate, count := range next {
+			if count > n || (count == n && candidate < asn) {
+				asn, n = candidate, count
+			}
+		}
+		if n == 0 || float64(n) < needed {
+			break
+		}
+		consensus.Suffix = append([]int{asn}, consensus.Suffix...)
+		consensus.SuffixPeers = n
+	}
+
+	if len(consensus.Suffix) > 0 {
+		for i, path := range paths {
+			if !hasSuffix(path, consensus.Suffix) {
+				consensus.Outliers = append(consensus.Outliers, ConsensusOutlier{
+					Peer: names[i],
+					Diff: DiffPath(fromNeighbour(path), consensus.Path),
+				})
+			}
+		}
+	}
+	return consensus
+}
+
+// Follows reports whether the peer's path ends with the consensus suffix.
+// Without a consensus suffix every peer follows it.
+func (c Consensus) Follows(peer *parser.Peer) bool {
+	path, _ := CollapsedPath(peer)
+	return len(c.Suffix) == 0 || len(path) == 0 || hasSuffix(path, c.Suffix)
+}
+
+// Diff compares the peer's collapsed path with the consensus path from
+// the neighbour onward: every peer starts with its own AS, which is not a
+// difference in the route it chose.
+func (c Consensus) Diff(peer *parser.Peer) PathDiff {
+	path, _ := CollapsedPath(peer)
+	return DiffPath(fromNeighbour(path), c.Path)
+}
+
+// fromNeighbour drops the first AS of a path, the peer's own, unless it is
+// the only one.
+func fromNeighbour(path []int) []int {
+	if len(path) > 1 {
+		return path[1:]
+	}
+	return path
+}
+
+// DiffPath aligns path with the consensus path through their longest common
+// subsequence.
+func DiffPath(path, consensus []int) PathDiff {
+	// lcs[i][j] is the LCS length of path[i:] and consensus[j:]
+	lcs := make([][]int, len(path)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(consensus)+1)
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		for j := len(consensus) - 1; j >= 0; j-- {
+			if path[i] == consensus[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var diff PathDiff
+	i, j := 0, 0
+	for i < len(path) || j < len(consensus) {
+		switch {
+		case i < len(path) && j < len(consensus) && path[i] == consensus[j]:
+			diff = append(diff, DiffOp{ASN: path[i], Op: DiffSame})
+			i++
+			j++
+		case j < len(consensus) && (i == len(path) || lcs[i][j+1] >= lcs[i+1][j]):
+			diff = append(diff, DiffOp{ASN: consensus[j], Op: DiffMissing})
+			j++
+		default:
+			diff = append(diff, DiffOp{ASN: path[i], Op: DiffAdded})
+			i++
+		}
+	}
+	return diff
+}
+
+// hasSuffix reports whether path ends with suffix.
+func hasSuffix(path, suffix []int) bool {
+	return len(path) >= len(suffix) && slices.Equal(path[len(path)-len(suffix):], suffix)
+}
+
+// pathKey identifies a collapsed path in a map.
+func pathKey(path []int) string {
+	return fmt.Sprint(path)
+}
@@ -0,0 +1,43 @@
+package analysis
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/drksbr/lg2/pkg/parser"
+)
+
+// testPeer builds a peer whose AS_PATH is the given sequence.
+func testPeer(name string, asns ...int) parser.Peer {
+	peer := parser.Peer{PeerName: name}
+	for _, asn := range asns {
+		peer.AsPath = append(peer.AsPath, parser.AsPath{AsNumber: asn})
+	}
+	return peer
+}
+
+func TestFindConsensusIgnoresPeerAS(t *testing.T) {
+	peers := []parser.Peer{
+		testPeer("a", 64501, 3356, 174, 64500),
+		testPeer("b", 64502, 3356, 174, 64500),
+		testPeer("c", 64503, 3356, 174, 64500),
+		testPeer("d", 64504, 1299, 64500),
+	}
+	consensus := FindConsensus(peers)
+
+	if want := []int{3356, 174, 64500}; !slices.Equal(consensus.Path, want) {
+		t.Errorf("Path = %v, want %v", consensus.Path, want)
+	}
+	if consensus.PathPeers != 3 {
+		t.Errorf("PathPeers = %d, want 3", consensus.PathPeers)
+	}
+	for _, name := range []string{"a", "b", "c"} {
+		i := slices.IndexFunc(peers, func(p parser.Peer) bool { return p.PeerName == name })
+		if diff := consensus.Diff(&peers[i]); !diff.Same() {
+			t.Errorf("peer %s: diff %s, want none", name, diff)
+		}
+	}
+	if diff := consensus.Diff(&peers[3]); diff.String() != "-3356 -174 +1299 64500" {
+		t.Errorf("peer d: diff %s", diff)
+	}
+}
@@ -0,0 +1,75 @@
+package analysis
+
+import "github.com/drksbr/lg2/pkg/parser"
+
+// DualStackRow lines up the IPv4 and IPv6 routes seen by one peer.
+type DualStackRow struct {
+	PeerName         string
+	V4               *parser.Peer // nil se o peer não tem rota IPv4
+	V6               *parser.Peer // nil se o peer não tem rota IPv6
+	OriginDiverges   bool         // Origem diferente entre as famílias
+	UpstreamDiverges bool         // Upstream (AS antes da origem) diferente
+}
+
+// DualStackSummary counts the rows of a dual-stack comparison.
+type DualStackSummary struct {
+	Both             int
+	OnlyV4           int
+	OnlyV6           int
+	OriginDiverges   int
+	UpstreamDiverges int
+}
+
+// CompareDualStack pairs the peers of an IPv4 and an IPv6 result set by peer
+// name, keeping the IPv4 order and appending IPv6-only peers at the end.
+func CompareDualStack(v4, v6 []parser.Peer) ([]DualStackRow, DualStackSummary) {
+	var rows []DualStackRow
+	var summary DualStackSummary
+
+	v6ByName := make(map[string]*parser.Peer, len(v6))
+	for i := range v6 {
+		v6ByName[v6[i].PeerName] = &v6[i]
+	}
+
+	seen := make(map[string]bool, len(v4))
+	for i := range v4 {
+		row := DualStackRow{PeerName: v4[i].PeerName, V4: &v4[i], V6: v6ByName[v4[i].PeerName]}
+		seen[row.PeerName] = true
+		rows = append(rows, row)
+	}
+	for i := range v6 {
+		if !seen[v6[i].PeerName] {
+			rows = append(rows, DualStackRow{PeerName: v6[i].PeerName, V6: &v6[i]})
+		}
+	}
+
+	for i := range rows {
+		row := &rows[i]
+		switch {
+		case row.V4 == nil:
+			summary.OnlyV6++
+			continue
+		case row.V6 == nil:
+			summary.OnlyV4++
+			continue
+		}
+		summary.Both++
+
+		origin4, ok4 := row.V4.OriginAS()
+		origin6, ok6 := row.V6.OriginAS()
+		row.OriginDiverges = ok4 != ok6 || origin4 != origin6
+
+		upstream4, ok4 := Upstream(row.V4)
+		upstream6, ok6 := Upstream(row.V6)
+		row.UpstreamDiverges = ok4 != ok6 || upstream4 != upstream6
+
+		if row.OriginDiverges {
+			summary.OriginDiverges++
+		}
+		if row.UpstreamDiverges {
+			summary.UpstreamDiverges++
+		}
+	}
+
+	return rows, summary
+}
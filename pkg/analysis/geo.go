@@ -0,0 +1,110 @@
+package analysis
+
+import (
+	"strings"
+
+	"github.com/drksbr/lg2/pkg/parser"
+)
+
+// GeoPath is the sequence of countries a route crosses, from the peer to
+// the origin. AS countries are registration countries, so this is a hint of
+// where traffic goes, not a measurement.
+type GeoPath struct {
+	Countries    []string `json:"countries"`
+	Trombones    []string `json:"trombones,omitempty"` // Ex: "BR→US→BR"
+	LeavesRegion bool     `json:"leaves_region,omitempty"`
+	Outside      []string `json:"outside,omitempty"` // Países fora da região local
+}
+
+// PeerCountry returns the country of the peer's location (--nodes), or the
+// registration country of the peer's own AS when the location is unknown.
+func PeerCountry(peer *parser.Peer) string {
+	if country := strings.ToUpper(strings.TrimSpace(peer.Info.Country)); country != "" {
+		return country
+	}
+	if len(peer.AsPath) > 0 {
+		return strings.ToUpper(strings.TrimSpace(peer.AsPath[0].Country))
+	}
+	return ""
+}
+
+// CountryPath returns the countries from the peer's location through every
+// AS in the path, with consecutive repeats and unknown countries removed.
+func CountryPath(peer *parser.Peer) []string {
+	var countries []string
+	add := func(country string) {
+		country = strings.ToUpper(strings.TrimSpace(country))
+		if country == "" || (len(countries) > 0 && countries[len(countries)-1] == country) {
+			return
+		}
+		countries = append(countries, country)
+	}
+
+	add(peer.Info.Country)
+	hops, _ := CollapsedHops(peer)
+	for _, hop := range hops {
+		add(hop.Country)
+	}
+	return countries
+}
+
+// AnalyzeGeo derives the country path and flags tromboning (leaving a
+// country and coming back to it). When local is not empty, a path that
+// starts and ends in the local region but crosses countries outside it is
+// flagged as leaving the region.
+func AnalyzeGeo(peer *parser.Peer, local map[string]bool) GeoPath {
+	geo := GeoPath{Countries: CountryPath(peer)}
+
+	seen := map[string]bool{}
+	for i, country := range geo.Countries {
+		for j := i + 2; j < len(geo.Countries) && !seen[country]; j++ {
+			if geo.Countries[j] == country {
+				geo.Trombones = append(geo.Trombones, strings.Join(geo.Countries[i:j+1], "→"))
+				seen[country] = true
+			}
+		}
+	}
+
+	if n := len(geo.Countries); len(local) > 0 && n > 0 && local[geo.Countries[0]] && local[geo.Countries[n-1]] {
+		for _, country := range geo.Countries {
+			if !local[country] {
+				geo.LeavesRegion = true
+				geo.Outside = append(geo.Outside, country)
+			}
+		}
+	}
+	return geo
+}
+
+// GeoPeer is a peer flagged by the geographic analysis.
+type GeoPeer struct {
+	Peer string `json:"peer"`
+	Path string `json:"path"`
+}
+
+// GeoSummary lists the peers whose routes trombone or leave the region.
+type GeoSummary struct {
+	Peers         int       `json:"peers"` // Peers com ao menos um país conhecido
+	Tromboning    []GeoPeer `json:"tromboning,omitempty"`
+	LeavingRegion []GeoPeer `json:"leaving_region,omitempty"`
+}
+
+// SummarizeGeo runs AnalyzeGeo on every peer.
+func SummarizeGeo(peers []parser.Peer, local map[string]bool) GeoSummary {
+	var summary GeoSummary
+	for i := range peers {
+		geo := AnalyzeGeo(&peers[i], local)
+		if len(geo.Countries) == 0 {
+			continue
+		}
+		summary.Peers++
+		path := strings.Join(geo.Countries, "→")
+		if len(geo.Trombones) > 0 {
+			summary.Tromboning = append(summary.Tromboning, GeoPeer{Peer: peers[i].PeerName, Path: path})
+		}
+		if geo.LeavesRegion {
+			summary.LeavingRegion = append(summary.LeavingRegion, GeoPeer{Peer: peers[i].PeerName, Path: path})
+		}
+	}
+	return summary
+}
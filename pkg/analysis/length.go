@@ -0,0 +1,121 @@
+package analysis
+
+import (
+	"sort"
+
+	"github.com/drksbr/lg2/pkg/parser"
+)
+
+// A path is an outlier when it is at least OutlierMargin ASNs longer than
+// the median of its country, among countries with MinCountryPeers peers.
+const (
+	OutlierMargin   = 2
+	MinCountryPeers = 3
+)
+
+// LengthBucket is one bar of the path length histogram.
+type LengthBucket struct {
+	Length int `json:"length"`
+	Peers  int `json:"peers"`
+}
+
+// LengthOutlier is a peer whose path is much longer than those of the other
+// peers in its country.
+type LengthOutlier struct {
+	Peer          string  `json:"peer"`
+	Country       string  `json:"country"`
+	Length        int     `json:"length"`
+	CountryMedian float64 `json:"country_median"`
+}
+
+// LengthStats summarises the AS_PATH lengths seen by the peers, counted as
+// in RFC 4271: prepends count, an AS_SET counts as one and confederation
+// segments are not counted.
+type LengthStats struct {
+	Peers     int             `json:"peers"`
+	Histogram []LengthBucket  `json:"histogram"`
+	Mean      float64         `json:"mean"`
+	Median    float64         `json:"median"`
+	Shortest  int             `json:"shortest"`
+	Longest   int             `json:"longest"`
+	Short     []string        `json:"shortest_peers"` // Peers com o caminho mais curto
+	Long      []string        `json:"longest_peers"`  // Peers com o caminho mais longo
+	Outliers  []LengthOutlier `json:"outliers,omitempty"`
+}
+
+// PathLengthStats computes the length statistics across the peers and the
+// per-country outliers.
+func PathLengthStats(peers []parser.Peer) LengthStats {
+	var stats LengthStats
+	if len(peers) == 0 {
+		return stats
+	}
+	stats.Peers = len(peers)
+
+	lengths := make([]int, len(peers))
+	counts := map[int]int{}
+	byCountry := map[string][]int{}
+	total := 0
+	for i := range peers {
+		length := peers[i].PathLength()
+		lengths[i] = length
+		counts[length]++
+		total += length
+		if country := PeerCountry(&peers[i]); country != "" {
+			byCountry[country] = append(byCountry[country], length)
+		}
+	}
+
+	for length, n := range counts {
+		stats.Histogram = append(stats.Histogram, LengthBucket{Length: length, Peers: n})
+	}
+	sort.Slice(stats.Histogram, func(i, j int) bool { return stats.Histogram[i].Length < stats.Histogram[j].Length })
+
+	stats.Mean = float64(total) / float64(len(peers))
+	stats.Median = median(lengths)
+	stats.Shortest = stats.Histogram[0].Length
+	stats.Longest = stats.Histogram[len(stats.Histogram)-1].Length
+	for i := range peers {
+		// Both when every path has the same length
+		if lengths[i] == stats.Shortest {
+			stats.Short = append(stats.Short, peers[i].PeerName)
+		}
+		if lengths[i] == stats.Longest {
+			stats.Long = append(stats.Long, peers[i].PeerName)
+		}
+	}
+
+	countryMedian := map[string]float64{}
+	for country, values := range byCountry {
+		if len(values) >= MinCountryPeers {
+			countryMedian[country] = median(values)
+		}
+	}
+	for i := range peers {
+		country := PeerCountry(&peers[i])
+		m, ok := countryMedian[country]
+		if ok && float64(lengths[i]) >= m+OutlierMargin {
+			stats.Outliers = append(stats.Outliers, LengthOutlier{
+				Peer:          peers[i].PeerName,
+				Country:       country,
+				Length:        lengths[i],
+				CountryMedian: m,
+			})
+		}
+	}
+	return stats
+}
+
+// median returns the median of the values without changing their order.
+func median(values []int) float64 {
+	sorted := append([]int{}, values...)
+	sort.Ints(sorted)
+	n := len(sorted)
+	if n == 0 {
+		return 0
+	}
+	if n%2 == 1 {
+		return float64(sorted[n/2])
+	}
+	return float64(sorted[n/2-1]+sorted[n/2]) / 2
+}
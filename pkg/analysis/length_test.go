@@ -0,0 +1,27 @@
+package analysis
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/drksbr/lg2/pkg/parser"
+)
+
+func TestPathLengthStatsEqualLengths(t *testing.T) {
+	peers := []parser.Peer{
+		testPeer("a", 64501, 3356, 64500),
+		testPeer("b", 64502, 174, 64500),
+	}
+	stats := PathLengthStats(peers)
+
+	if stats.Shortest != 3 || stats.Longest != 3 {
+		t.Fatalf("Shortest, Longest = %d, %d, want 3, 3", stats.Shortest, stats.Longest)
+	}
+	want := []string{"a", "b"}
+	if !slices.Equal(stats.Short, want) {
+		t.Errorf("Short = %v, want %v", stats.Short, want)
+	}
+	if !slices.Equal(stats.Long, want) {
+		t.Errorf("Long = %v, want %v", stats.Long, want)
+	}
+}
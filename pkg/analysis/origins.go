@@ -0,0 +1,41 @@
+package analysis
+
+import (
+	"sort"
+
+	"github.com/drksbr/lg2/pkg/parser"
+)
+
+// OriginCount is an origin AS and how many peers see it.
+type OriginCount struct {
+	Origin    int  `json:"origin"`
+	HasOrigin bool `json:"has_origin"` // false quando o caminho termina em AS_SET
+	Peers     int  `json:"peers"`
+}
+
+// Origins returns the origin set across the peers, most seen first. More
+// than one entry means the prefix is MOAS: anycast, a multi-homed customer
+// or a hijack.
+func Origins(peers []parser.Peer) []OriginCount {
+	type key struct {
+		origin    int
+		hasOrigin bool
+	}
+	counts := map[key]int{}
+	for i := range peers {
+		origin, ok := peers[i].OriginAS()
+		counts[key{origin, ok}]++
+	}
+
+	result := make([]OriginCount, 0, len(counts))
+	for k, n := range counts {
+		result = append(result, OriginCount{Origin: k.origin, HasOrigin: k.hasOrigin, Peers: n})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Peers != result[j].Peers {
+			return result[i].Peers > result[j].Peers
+		}
+		return result[i].Origin < result[j].Origin
+	})
+	return result
+}
@@ -0,0 +1,58 @@
+// Package analysis derives routing insights from the peers returned by the
+// looking glass.
+package analysis
+
+import "github.com/drksbr/lg2/pkg/parser"
+
+// CollapsedPath returns the ASNs of the peer's AS_PATH in order with
+// prepends removed. Confederation segments are skipped, and an AS_SET is
+// kept only as a terminator: the returned path stops before it and
+// endsInSet is true.
+func CollapsedPath(peer *parser.Peer) (path []int, endsInSet bool) {
+	hops, endsInSet := CollapsedHops(peer)
+	for _, hop := range hops {
+		path = append(path, hop.AsNumber)
+	}
+	return path, endsInSet
+}
+
+// CollapsedHops is CollapsedPath keeping the name and country of each AS.
+func CollapsedHops(peer *parser.Peer) (hops []parser.AsPath, endsInSet bool) {
+	for _, segment := range peer.PathSegments() {
+		if segment.Type.IsConfed() {
+			continue
+		}
+		if segment.Type == parser.SegmentSet {
+			return hops, true
+		}
+		for _, as := range segment.ASNs {
+			if len(hops) == 0 || hops[len(hops)-1].AsNumber != as.AsNumber {
+				hops = append(hops, as)
+			}
+		}
+	}
+	return hops, false
+}
+
+// OriginHops is CollapsedHops ending at the first occurrence of the origin.
+// A poisoned path ("… U X P X") ends in ASes the origin inserted after
+// itself; without them the AS before the origin is the real upstream U.
+func OriginHops(peer *parser.Peer) (hops []parser.AsPath, endsInSet bool) {
+	hops, endsInSet = CollapsedHops(peer)
+	if endsInSet || len(hops) == 0 {
+		return hops, endsInSet
+	}
+	origin := hops[len(hops)-1].AsNumber
+	for i, hop := range hops {
+		if hop.AsNumber == origin {
+			return hops[:i+1], false
+		}
+	}
+	return hops, false
+}
+
+// Upstream returns the AS adjacent to the origin in the peer's path.
+func Upstream(peer *parser.Peer) (int, bool) {
+	hop, ok := HopAbove(peer, 1)
+	return hop.AsNumber, ok
+}
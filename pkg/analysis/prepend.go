@@ -0,0 +1,158 @@
+package analysis
+
+import (
+	"sort"
+
+	"github.com/drksbr/lg2/pkg/parser"
+)
+
+// Tier1 lists the transit-free networks commonly inserted when poisoning a
+// path to steer traffic away from them.
+var Tier1 = map[int]string{
+	174:   "Cogent",
+	701:   "Verizon",
+	1239:  "Sprint",
+	1299:  "Arelion",
+	2828:  "Verizon (XO)",
+	2914:  "NTT",
+	3257:  "GTT",
+	3320:  "Deutsche Telekom",
+	3356:  "Lumen",
+	3491:  "PCCW",
+	5511:  "Orange",
+	6453:  "Tata",
+	6461:  "Zayo",
+	6762:  "Telecom Italia Sparkle",
+	6830:  "Liberty Global",
+	7018:  "AT&T",
+	12956: "Telefonica",
+}
+
+// Prepend is an AS repeated consecutively in a path.
+type Prepend struct {
+	ASN    int  `json:"asn"`
+	Count  int  `json:"count"` // Cópias extras além da primeira
+	Origin bool `json:"origin"`
+}
+
+// Poisoning is an AS that reappears in the path after other ASNs, which
+// only happens when it was inserted on purpose.
+type Poisoning struct {
+	ASN     int   `json:"asn"`
+	Origin  bool  `json:"origin"`
+	Between []int `json:"between"` // ASNs entre as ocorrências
+	Tier1   []int `json:"tier1,omitempty"`
+}
+
+// Prepending is the prepending analysis of one route.
+type Prepending struct {
+	Prepends  []Prepend   `json:"prepends,omitempty"`
+	Poisoning []Poisoning `json:"poisoning,omitempty"`
+}
+
+// AnalyzePrepending finds the ASes that prepend in the peer's AS_PATH and
+// ASes that reappear after foreign ASNs, a sign of path poisoning.
+// Confederation segments and AS_SETs are ignored.
+func AnalyzePrepending(peer *parser.Peer) Prepending {
+	// Runs of the same ASN in path order
+	type run struct{ asn, count int }
+	var runs []run
+	for _, segment := range peer.PathSegments() {
+		if segment.Type != parser.SegmentSequence {
+			continue
+		}
+		for _, as := range segment.ASNs {
+			if len(runs) > 0 && runs[len(runs)-1].asn == as.AsNumber {
+				runs[len(runs)-1].count++
+				continue
+			}
+			runs = append(runs, run{as.AsNumber, 1})
+		}
+	}
+
+	var result Prepending
+	origin, hasOrigin := peer.OriginAS()
+	isOrigin := func(asn int) bool { return hasOrigin && asn == origin }
+
+	for _, r := range runs {
+		if r.count > 1 {
+			result.Prepends = append(result.Prepends, Prepend{ASN: r.asn, Count: r.count - 1, Origin: isOrigin(r.asn)})
+		}
+	}
+
+	// An ASN in two separate runs: report what was inserted between them
+	lastRun := map[int]int{}
+	for i, r := range runs {
+		if previous, seen := lastRun[r.asn]; seen {
+			poison := Poisoning{ASN: r.asn, Origin: isOrigin(r.asn)}
+			for _, between := range runs[previous+1 : i] {
+				poison.Between = append(poison.Between, between.asn)
+				if _, ok := Tier1[between.asn]; ok {
+					poison.Tier1 = append(poison.Tier1, between.asn)
+				}
+			}
+			result.Poisoning = append(result.Poisoning, poison)
+		}
+		lastRun[r.asn] = i
+	}
+	return result
+}
+
+// OriginPrepends returns how many extra copies of the origin the path has.
+func (p Prepending) OriginPrepends() int {
+	for _, prepend := range p.Prepends {
+		if prepend.Origin {
+			return prepend.Count
+		}
+	}
+	return 0
+}
+
+// UpstreamPrepending summarises the routes that reach the origin through
+// one upstream.
+type UpstreamPrepending struct {
+	Upstream       int     `json:"upstream"`
+	Peers          int     `json:"peers"`
+	OriginPrepends int     `json:"origin_prepends"` // Maior prepend da origem visto via este upstream
+	Prepended      int     `json:"prepended"`       // Peers que viram a origem prependada
+	MeanLength     float64 `json:"mean_length"`
+}
+
+// SummarizePrepending groups the peers by upstream (the AS adjacent to the
+// origin) so the effect of the origin's prepending on path selection shows:
+// upstreams it prepends towards should be picked by fewer peers. Sorted by
+// peer count.
+func SummarizePrepending(peers []parser.Peer) []UpstreamPrepending {
+	byUpstream := map[int]*UpstreamPrepending{}
+	lengths := map[int]int{}
+	for i := range peers {
+		upstream, ok := Upstream(&peers[i])
+		if !ok {
+			continue
+		}
+		summary := byUpstream[upstream]
+		if summary == nil {
+			summary = &UpstreamPrepending{Upstream: upstream}
+			byUpstream[upstream] = summary
+		}
+		summary.Peers++
+		lengths[upstream] += peers[i].PathLength()
+		if count := AnalyzePrepending(&peers[i]).OriginPrepends(); count > 0 {
+			summary.Prepended++
+			summary.OriginPrepends = max(summary.OriginPrepends, count)
+		}
+	}
+
+	result := make([]UpstreamPrepending, 0, len(byUpstream))
+	for upstream, summary := range byUpstream {
+		summary.MeanLength = float64(lengths[upstream]) / float64(summary.Peers)
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Peers != result[j].Peers {
+			return result[i].Peers > result[j].Peers
+		}
+		return result[i].Upstream < result[j].Upstream
+	})
+	return result
+}
@@ -0,0 +1,113 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+
+	"github.com/drksbr/lg2/pkg/parser"
+)
+
+// UpstreamShare is how many peers reach the origin through one AS.
+type UpstreamShare struct {
+	ASN     int     `json:"asn"`
+	Name    string  `json:"name,omitempty"`
+	Country string  `json:"country,omitempty"`
+	Peers   int     `json:"peers"`
+	Percent float64 `json:"percent"`
+}
+
+// CountryShare is how many peers reach the origin through ASes of one
+// country.
+type CountryShare struct {
+	Country string  `json:"country"`
+	Peers   int     `json:"peers"`
+	Percent float64 `json:"percent"`
+}
+
+// UpstreamDistribution is the share of peers per AS at one level above the
+// origin: level 1 is the AS adjacent to the origin, level 2 the next hop up.
+type UpstreamDistribution struct {
+	Level     int             `json:"level"`
+	Peers     int             `json:"peers"` // Peers com caminho longo o bastante
+	Upstreams []UpstreamShare `json:"upstreams"`
+	Countries []CountryShare  `json:"countries"`
+}
+
+// HopAbove returns the AS level hops above the origin in the peer's path,
+// ignoring ASes inserted after the origin by poisoning. Paths without an
+// origin (ending in an AS_SET) have no hops above it.
+func HopAbove(peer *parser.Peer, level int) (parser.AsPath, bool) {
+	if _, ok := peer.OriginAS(); !ok || level < 1 {
+		return parser.AsPath{}, false
+	}
+	hops, _ := OriginHops(peer)
+	if len(hops) <= level {
+		return parser.AsPath{}, false
+	}
+	return hops[len(hops)-1-level], true
+}
+
+// DistributeUpstreams counts the peers per AS at the given level above the
+// origin, with a breakdown by the country of that AS. Both lists are sorted
+// by peer count.
+func DistributeUpstreams(peers []parser.Peer, level int) UpstreamDistribution {
+	dist := UpstreamDistribution{Level: level}
+	byASN := map[int]*UpstreamShare{}
+	byCountry := map[string]*CountryShare{}
+
+	for i := range peers {
+		hop, ok := HopAbove(&peers[i], level)
+		if !ok {
+			continue
+		}
+		dist.Peers++
+
+		share := byASN[hop.AsNumber]
+		if share == nil {
+			share = &UpstreamShare{ASN: hop.AsNumber, Name: hop.AsName, Country: hop.Country}
+			byASN[hop.AsNumber] = share
+		}
+		share.Peers++
+
+		country := hop.Country
+		if country == "" {
+			country = "??"
+		}
+		if byCountry[country] == nil {
+			byCountry[country] = &CountryShare{Country: country}
+		}
+		byCountry[country].Peers++
+	}
+
+	for _, share := range byASN {
+		share.Percent = percent(share.Peers, dist.Peers)
+		dist.Upstreams = append(dist.Upstreams, *share)
+	}
+	for _, share := range byCountry {
+		share.Percent = percent(share.Peers, dist.Peers)
+		dist.Countries = append(dist.Countries, *share)
+	}
+	sort.Slice(dist.Upstreams, func(i, j int) bool {
+		a, b := dist.Upstreams[i], dist.Upstreams[j]
+		if a.Peers != b.Peers {
+			return a.Peers > b.Peers
+		}
+		return a.ASN < b.ASN
+	})
+	sort.Slice(dist.Countries, func(i, j int) bool {
+		a, b := dist.Countries[i], dist.Countries[j]
+		if a.Peers != b.Peers {
+			return a.Peers > b.Peers
+		}
+		return a.Country < b.Country
+	})
+	return dist
+}
+
+// percent returns n as a percentage of total, rounded to one decimal.
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(1000*float64(n)/float64(total)) / 10
+}
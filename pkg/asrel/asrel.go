@@ -0,0 +1,159 @@
+// Package asrel loads CAIDA-style AS relationship files and checks AS paths
+// for valley-free routing.
+package asrel
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Relationship is what a neighbor is to an AS.
+type Relationship int
+
+const (
+	Unknown  Relationship = iota // Sem dados para o par
+	Customer                     // O vizinho é cliente
+	Provider                     // O vizinho é provedor
+	Peer                         // Peering (p2p)
+	Sibling                      // Mesma organização
+)
+
+// String returns the name of the relationship.
+func (r Relationship) String() string {
+	switch r {
+	case Customer:
+		return "customer"
+	case Provider:
+		return "provider"
+	case Peer:
+		return "peer"
+	case Sibling:
+		return "sibling"
+	default:
+		return "unknown"
+	}
+}
+
+// Set holds the relationships of every AS pair in a file.
+type Set struct {
+	rels  map[[2]uint32]Relationship
+	links int
+}
+
+// NewSet returns an empty set.
+func NewSet() *Set {
+	return &Set{rels: map[[2]uint32]Relationship{}}
+}
+
+// Len returns the number of AS links in the set.
+func (s *Set) Len() int {
+	if s == nil {
+		return 0
+	}
+	return s.links
+}
+
+// Add records the relationship of b as seen from a.
+func (s *Set) Add(a, b uint32, rel Relationship) {
+	if _, exists := s.rels[[2]uint32{a, b}]; !exists {
+		s.links++
+	}
+	s.rels[[2]uint32{a, b}] = rel
+	s.rels[[2]uint32{b, a}] = reverse(rel)
+}
+
+func reverse(rel Relationship) Relationship {
+	switch rel {
+	case Customer:
+		return Provider
+	case Provider:
+		return Customer
+	default:
+		return rel
+	}
+}
+
+// Relationship returns what b is to a.
+func (s *Set) Relationship(a, b uint32) Relationship {
+	if s == nil {
+		return Unknown
+	}
+	return s.rels[[2]uint32{a, b}]
+}
+
+// LoadFile reads a CAIDA as-rel file ("a|b|-1" when a is a provider of b,
+// "a|b|0" for peers, "a|b|1" for siblings). Plain, gzip and bzip2 files are
+// accepted; "#" lines and extra fields are ignored.
+func LoadFile(path string) (*Set, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open AS relationship file: %v", err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	var r io.Reader = reader
+	magic, _ := reader.Peek(3)
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	case bytes.Equal(magic, []byte("BZh")):
+		r = bzip2.NewReader(reader)
+	}
+
+	set, err := Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return set, nil
+}
+
+// Parse reads relationships in the CAIDA as-rel format.
+func Parse(r io.Reader) (*Set, error) {
+	set := NewSet()
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, "|")
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("line %d: expected \"as1|as2|relationship\"", line)
+		}
+		a, errA := strconv.ParseUint(fields[0], 10, 32)
+		b, errB := strconv.ParseUint(fields[1], 10, 32)
+		if errA != nil || errB != nil {
+			return nil, fmt.Errorf("line %d: invalid ASN", line)
+		}
+		switch fields[2] {
+		case "-1":
+			set.Add(uint32(a), uint32(b), Customer)
+		case "0":
+			set.Add(uint32(a), uint32(b), Peer)
+		case "1":
+			set.Add(uint32(a), uint32(b), Sibling)
+		default:
+			return nil, fmt.Errorf("line %d: unknown relationship %q", line, fields[2])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
@@ -0,0 +1,101 @@
+package asrel
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/drksbr/lg2/pkg/analysis"
+	"github.com/drksbr/lg2/pkg/parser"
+)
+
+// Link is one hop of a route's propagation: From announced it to To.
+type Link struct {
+	From, To uint32
+	Rel      Relationship // O que To é para From
+}
+
+// Kind returns the link in CAIDA notation: c2p when the route goes up to a
+// provider, p2p across a peering, p2c down to a customer.
+func (l Link) Kind() string {
+	switch l.Rel {
+	case Provider:
+		return "c2p"
+	case Peer:
+		return "p2p"
+	case Customer:
+		return "p2c"
+	case Sibling:
+		return "s2s"
+	default:
+		return "?"
+	}
+}
+
+// Leak is a link that breaks valley-free routing or the OTC attribute.
+type Leak struct {
+	ASN    uint32 // AS que vazou a rota
+	Link   Link
+	Valley bool // Rota de provedor/peer enviada a provedor/peer
+	OTC    bool // Rota com OTC enviada a provedor/peer
+}
+
+// PathCheck is the valley-free analysis of one route.
+type PathCheck struct {
+	Links  []Link // Da origem até o vizinho do peer
+	Leaks  []Leak
+	OTC    uint32 // AS no atributo OTC, se houver
+	HasOTC bool
+}
+
+var otcASN = regexp.MustCompile(`\d+`)
+
+// ParseOTC extracts the ASN of the Only To Customer attribute reported by
+// the looking glass.
+func ParseOTC(text string) (uint32, bool) {
+	match := otcASN.FindString(text)
+	if match == "" {
+		return 0, false
+	}
+	asn, err := strconv.ParseUint(match, 10, 32)
+	if err != nil || asn == 0 {
+		return 0, false
+	}
+	return uint32(asn), true
+}
+
+// CheckPeer annotates each link of the peer's path, from the origin up,
+// and flags the ASes that sent a route learned from a provider or peer to
+// another provider or peer (RFC 7908 type 1-4 leaks). Links with unknown
+// relationships are not judged. When the LG reports an OTC attribute
+// (RFC 9234), any AS after the one that set it sending the route up or
+// across is flagged too.
+func (s *Set) CheckPeer(peer *parser.Peer) PathCheck {
+	var check PathCheck
+	check.OTC, check.HasOTC = ParseOTC(peer.OnlyToCustomerOTC)
+
+	path, _ := analysis.CollapsedPath(peer)
+	otcSeen := false
+	descending := false
+	for i := len(path) - 1; i > 0; i-- {
+		link := Link{From: uint32(path[i]), To: uint32(path[i-1])}
+		link.Rel = s.Relationship(link.From, link.To)
+		check.Links = append(check.Links, link)
+
+		if check.HasOTC && link.From == check.OTC {
+			otcSeen = true
+		}
+		upOrAcross := link.Rel == Provider || link.Rel == Peer
+
+		leak := Leak{ASN: link.From, Link: link}
+		leak.Valley = descending && upOrAcross
+		leak.OTC = otcSeen && link.From != check.OTC && upOrAcross
+		if leak.Valley || leak.OTC {
+			check.Leaks = append(check.Leaks, leak)
+		}
+
+		if link.Rel == Peer || link.Rel == Customer {
+			descending = true
+		}
+	}
+	return check
+}
@@ -0,0 +1,241 @@
+// Package bogon flags ASNs and prefixes that should never appear in the
+// global routing table.
+package bogon
+
+import (
+	"bufio"
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+
+	"github.com/drksbr/lg2/pkg/parser"
+	"github.com/drksbr/lg2/pkg/rpki"
+)
+
+// Longest prefix lengths accepted in the global table.
+const (
+	MaxIPv4Length = 24
+	MaxIPv6Length = 48
+)
+
+// ASNRange is a block of ASNs that must not appear in AS paths.
+type ASNRange struct {
+	First, Last uint32
+	Reason      string
+}
+
+// PrefixEntry is an address block that must not be routed.
+type PrefixEntry struct {
+	Prefix netip.Prefix
+	Reason string
+}
+
+// List holds the bogon ASNs and prefixes.
+type List struct {
+	ASNs     []ASNRange
+	Prefixes []PrefixEntry
+}
+
+// globalUnicast is the only IPv6 space allocated for global routing.
+var globalUnicast = netip.MustParsePrefix("2000::/3")
+
+// Default returns the IANA special-purpose ASNs and prefixes.
+func Default() *List {
+	list := &List{
+		ASNs: []ASNRange{
+			{0, 0, "reserved (RFC 7607)"},
+			{23456, 23456, "AS_TRANS (RFC 6793)"},
+			{64496, 64511, "documentation (RFC 5398)"},
+			{64512, 65534, "private (RFC 6996)"},
+			{65535, 65535, "reserved (RFC 7300)"},
+			{65536, 65551, "documentation (RFC 5398)"},
+			{65552, 131071, "reserved (IANA)"},
+			{4200000000, 4294967294, "private (RFC 6996)"},
+			{4294967295, 4294967295, "reserved (RFC 7300)"},
+		},
+	}
+	for _, entry := range []struct{ prefix, reason string }{
+		{"0.0.0.0/8", "\"this network\" (RFC 791)"},
+		{"10.0.0.0/8", "private (RFC 1918)"},
+		{"100.64.0.0/10", "shared CGN space (RFC 6598)"},
+		{"127.0.0.0/8", "loopback (RFC 1122)"},
+		{"169.254.0.0/16", "link local (RFC 3927)"},
+		{"172.16.0.0/12", "private (RFC 1918)"},
+		{"192.0.0.0/24", "IETF protocol assignments (RFC 6890)"},
+		{"192.0.2.0/24", "documentation (RFC 5737)"},
+		{"192.168.0.0/16", "private (RFC 1918)"},
+		{"198.18.0.0/15", "benchmarking (RFC 2544)"},
+		{"198.51.100.0/24", "documentation (RFC 5737)"},
+		{"203.0.113.0/24", "documentation (RFC 5737)"},
+		{"224.0.0.0/4", "multicast (RFC 5771)"},
+		{"240.0.0.0/4", "reserved (RFC 1112)"},
+		{"100::/64", "discard only (RFC 6666)"},
+		{"2001:2::/48", "benchmarking (RFC 5180)"},
+		{"2001:10::/28", "ORCHID (RFC 4843)"},
+		{"2001:db8::/32", "documentation (RFC 3849)"},
+		{"3fff::/20", "documentation (RFC 9637)"},
+		{"fc00::/7", "unique local (RFC 4193)"},
+		{"fe80::/10", "link local (RFC 4291)"},
+		{"ff00::/8", "multicast (RFC 4291)"},
+	} {
+		list.Prefixes = append(list.Prefixes, PrefixEntry{netip.MustParsePrefix(entry.prefix), entry.reason})
+	}
+	return list
+}
+
+// LoadFile adds the entries of a user bogon file to the list. Each line
+// holds a prefix, an ASN or an ASN range ("AS64500-AS64510"), optionally
+// followed by a reason; "#" starts a comment.
+func (l *List) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open bogon list: %v", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		reason := "in the local bogon list"
+		if len(fields) > 1 {
+			reason = strings.Join(fields[1:], " ")
+		}
+
+		if strings.Contains(fields[0], "/") {
+			prefix, err := netip.ParsePrefix(fields[0])
+			if err != nil {
+				return fmt.Errorf("%s: line %d: invalid prefix %q", path, line, fields[0])
+			}
+			l.Prefixes = append(l.Prefixes, PrefixEntry{prefix.Masked(), reason})
+			continue
+		}
+
+		first, last, _ := strings.Cut(fields[0], "-")
+		if last == "" {
+			last = first
+		}
+		from, err := rpki.ParseASN(first)
+		if err != nil {
+			return fmt.Errorf("%s: line %d: %v", path, line, err)
+		}
+		to, err := rpki.ParseASN(last)
+		if err != nil || to < from {
+			return fmt.Errorf("%s: line %d: invalid ASN range %q", path, line, fields[0])
+		}
+		l.ASNs = append(l.ASNs, ASNRange{from, to, reason})
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+// ASN returns the bogon range containing asn.
+func (l *List) ASN(asn uint32) (ASNRange, bool) {
+	if l == nil {
+		return ASNRange{}, false
+	}
+	for _, r := range l.ASNs {
+		if asn >= r.First && asn <= r.Last {
+			return r, true
+		}
+	}
+	return ASNRange{}, false
+}
+
+// Prefix returns the bogon entry containing prefix. IPv6 outside the
+// global unicast space (2000::/3) is always a bogon.
+func (l *List) Prefix(prefix netip.Prefix) (PrefixEntry, bool) {
+	if l == nil || !prefix.IsValid() {
+		return PrefixEntry{}, false
+	}
+	for _, entry := range l.Prefixes {
+		if entry.Prefix.Bits() <= prefix.Bits() && entry.Prefix.Contains(prefix.Addr()) {
+			return entry, true
+		}
+	}
+	if prefix.Addr().Is6() && !(prefix.Bits() >= globalUnicast.Bits() && globalUnicast.Contains(prefix.Addr())) {
+		return PrefixEntry{globalUnicast, "the only space for global unicast (RFC 4291)"}, true
+	}
+	return PrefixEntry{}, false
+}
+
+// Warning is a bogon found in a prefix or AS path.
+type Warning struct {
+	Kind    string // bogon-asn, bogon-prefix ou too-specific
+	Message string
+}
+
+// CheckPrefix flags bogon and documentation prefixes and prefixes longer
+// than /24 (IPv4) or /48 (IPv6).
+func (l *List) CheckPrefix(prefix netip.Prefix) []Warning {
+	if l == nil || !prefix.IsValid() {
+		return nil
+	}
+	var warnings []Warning
+	if entry, ok := l.Prefix(prefix); ok {
+		if entry.Prefix == globalUnicast {
+			warnings = append(warnings, Warning{"bogon-prefix", fmt.Sprintf("%s is a bogon: outside %s, %s", prefix, entry.Prefix, entry.Reason)})
+		} else {
+			warnings = append(warnings, Warning{"bogon-prefix", fmt.Sprintf("%s is a bogon: %s is %s", prefix, entry.Prefix, entry.Reason)})
+		}
+	}
+	maxLength := MaxIPv4Length
+	if prefix.Addr().Is6() {
+		maxLength = MaxIPv6Length
+	}
+	if prefix.Bits() > maxLength {
+		warnings = append(warnings, Warning{"too-specific", fmt.Sprintf("%s is longer than /%d and is filtered by most networks", prefix, maxLength)})
+	}
+	return warnings
+}
+
+// CheckAddr flags a queried address inside a bogon or documentation
+// prefix. The length check does not apply: the route found for the address
+// is checked with CheckPrefix.
+func (l *List) CheckAddr(addr netip.Addr) []Warning {
+	if l == nil || !addr.IsValid() {
+		return nil
+	}
+	entry, ok := l.Prefix(netip.PrefixFrom(addr, addr.BitLen()))
+	if !ok {
+		return nil
+	}
+	if entry.Prefix == globalUnicast {
+		return []Warning{{"bogon-prefix", fmt.Sprintf("%s is a bogon: outside %s, %s", addr, entry.Prefix, entry.Reason)}}
+	}
+	return []Warning{{"bogon-prefix", fmt.Sprintf("%s is a bogon: %s is %s", addr, entry.Prefix, entry.Reason)}}
+}
+
+// CheckPath flags bogon ASNs anywhere in the peer's AS path, AS_SETs
+// included. Confederation segments are skipped: member ASNs are usually
+// private and never leave the confederation.
+func (l *List) CheckPath(peer *parser.Peer) []Warning {
+	if l == nil {
+		return nil
+	}
+	var warnings []Warning
+	seen := map[int]bool{}
+	for _, segment := range peer.PathSegments() {
+		if segment.Type.IsConfed() {
+			continue
+		}
+		for _, as := range segment.ASNs {
+			if seen[as.AsNumber] {
+				continue
+			}
+			seen[as.AsNumber] = true
+			if r, ok := l.ASN(uint32(as.AsNumber)); ok {
+				warnings = append(warnings, Warning{"bogon-asn", fmt.Sprintf("AS%d in the AS path is %s", as.AsNumber, r.Reason)})
+			}
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/drksbr/lg2/pkg/config"
+	"github.com/drksbr/lg2/pkg/irr"
+	"github.com/drksbr/lg2/pkg/rpki"
+	"github.com/spf13/cobra"
+)
+
+var asSetCmd = &cobra.Command{
+	Use:   "as-set [flags] AS-SET|ASN...",
+	Short: "Expand AS-SETs recursively from local RPSL dumps",
+	Long: `Expands AS-SETs from the RPSL dumps given with --irr, following nested sets
+up to --depth levels. Loops, undefined sets and sets cut by the depth limit are
+reported. Given an ASN, expands the customer cone it announces in the export
+policies of its aut-num object.
+
+	lg as-set --irr radb.db.gz --irr ripe.db.gz AS-EXAMPLE
+	lg as-set --irr radb.db.gz AS65000`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runASSet,
+}
+
+func init() {
+	asSetCmd.Flags().StringArrayVar(&config.IRRFiles, "irr", nil, "dump RPSL (RADB, RIPE, ARIN; texto ou .gz) (repetível)")
+	asSetCmd.Flags().IntVar(&config.ASSetDepth, "depth", config.ASSetDepth, "profundidade máxima de aninhamento")
+	rootCmd.AddCommand(asSetCmd)
+}
+
+func runASSet(cmd *cobra.Command, args []string) error {
+	if len(config.IRRFiles) == 0 {
+		return fmt.Errorf("informe ao menos um dump RPSL com --irr")
+	}
+	db, err := irr.LoadDumps(config.IRRFiles)
+	if err != nil {
+		return err
+	}
+	db.MaxDepth = config.ASSetDepth
+
+	out := cmd.OutOrStdout()
+	for i, name := range args {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+
+		// An ASN expands to the cone declared in its aut-num
+		if asn, err := rpki.ParseASN(name); err == nil {
+			cone := db.Cone(asn)
+			if cone == nil {
+				fmt.Fprintf(out, "AS%d: no aut-num export policy announcing a customer cone\n", asn)
+				continue
+			}
+			fmt.Fprintf(out, "AS%d announces %s\n", asn, cone.Name)
+			printExpansion(out, cone, db.MaxDepth)
+			continue
+		}
+
+		set, ok := db.ASSet(name)
+		if !ok {
+			fmt.Fprintf(out, "%s: not found in the loaded dumps\n", strings.ToUpper(name))
+			continue
+		}
+		fmt.Fprintf(out, "%s (%s)\n", set.Name, set.Source)
+		printExpansion(out, db.Expand(name, db.MaxDepth), db.MaxDepth)
+	}
+	return nil
+}
+
+// printExpansion prints the ASNs and nested sets of an expansion followed by
+// its warnings.
+func printExpansion(out io.Writer, e *irr.Expansion, maxDepth int) {
+	fmt.Fprintf(out, "  %d ASNs from %d sets\n", len(e.ASNs), len(e.Sets))
+
+	const perLine = 8
+	for i := 0; i < len(e.ASNs); i += perLine {
+		var line []string
+		for _, asn := range e.ASNs[i:min(i+perLine, len(e.ASNs))] {
+			line = append(line, fmt.Sprintf("AS%d", asn))
+		}
+		fmt.Fprintf(out, "  %s\n", strings.Join(line, " "))
+	}
+	if len(e.Sets) > 0 {
+		fmt.Fprintf(out, "  Sets: %s\n", strings.Join(e.Sets, ", "))
+	}
+
+	for _, loop := range e.Loops {
+		fmt.Fprintf(out, "  Warning: loop %s\n", loop)
+	}
+	for _, name := range e.Missing {
+		fmt.Fprintf(out, "  Warning: %s is not defined in the loaded dumps\n", name)
+	}
+	for _, name := range e.Truncated {
+		fmt.Fprintf(out, "  Warning: depth limit %d reached at %s\n", maxDepth, name)
+	}
+}
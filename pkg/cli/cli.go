@@ -1,10 +1,24 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"strings"
+	"time"
+	_ "time/tzdata" // base de fusos embutida (Windows não tem uma)
 
+	"github.com/drksbr/lg2/pkg/asrel"
+	"github.com/drksbr/lg2/pkg/bogon"
+	"github.com/drksbr/lg2/pkg/community"
 	"github.com/drksbr/lg2/pkg/config"
+	"github.com/drksbr/lg2/pkg/ip2asn"
+	"github.com/drksbr/lg2/pkg/irr"
+	"github.com/drksbr/lg2/pkg/parser"
+	"github.com/drksbr/lg2/pkg/policy"
+	"github.com/drksbr/lg2/pkg/report"
+	"github.com/drksbr/lg2/pkg/ring"
+	"github.com/drksbr/lg2/pkg/rpki"
 	"github.com/drksbr/lg2/pkg/tui"
 	"github.com/spf13/cobra"
 )
@@ -22,37 +36,196 @@ var (
 	IPv6 usage example: lg 2001:db8::/32`
 
 	showVersion bool
+	jsonOutput  bool
+	checkOutput bool
 
 	rootCmd = &cobra.Command{
 		Use:   "lg [flags] [prefix]",
 		Short: "Looking Glass CLI for querying BGP prefixes",
 		Long:  fmt.Sprintf(Banner, config.Version),
+		Args:  cobra.ArbitraryArgs,
 		Run:   run,
+
+		PersistentPreRunE: setup,
+		SilenceUsage:      true,
 	}
 )
 
+// setup applies the settings shared by every command.
+func setup(cmd *cobra.Command, args []string) error {
+	// Fuso horário usado para interpretar o "Last update" do looking glass
+	loc, err := time.LoadLocation(config.SourceTimezone)
+	if err != nil {
+		return fmt.Errorf("fuso horário inválido %q: %v", config.SourceTimezone, err)
+	}
+	parser.SourceLocation = loc
+
+	// Resolver usado para consultas por hostname
+	switch {
+	case config.HostsFile != "":
+		hosts, err := parser.LoadHostsFile(config.HostsFile)
+		if err != nil {
+			return err
+		}
+		parser.DefaultResolver = hosts
+	case config.Resolver != "":
+		parser.DefaultResolver = parser.NewDNSResolver(config.Resolver)
+	}
+
+	return nil
+}
+
 func run(cmd *cobra.Command, args []string) {
 	if showVersion {
 		fmt.Printf("lg version %s\n", config.Version)
 		return
 	}
 
+	// Carregar os dados locais opcionais
+	opts, err := loadOptions()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// Saída não interativa para scripts e monitoramento
+	if jsonOutput || checkOutput {
+		os.Exit(runReport(cmd, args, opts))
+	}
+
 	// Se não houver argumentos, exibir a interface interativa
 	if len(args) == 0 {
 		// // Exibir interface interativa
-		t := tui.NewTUI("")
+		t := tui.NewTUI("", opts)
 		t.Start()
 		return
 	}
 
 	// Se houver argumentos, exibir o resultado da consulta
 	// // Exibir interface interativa
-	t := tui.NewTUI(args[0])
+	t := tui.NewTUI(args[0], opts)
 	t.Start()
 }
 
+// loadOptions loads the local datasets given on the command line.
+func loadOptions() (report.Options, error) {
+	opts := report.Options{Bogons: bogon.Default(), Communities: community.Default()}
+
+	nodes, err := loadNodes()
+	if err != nil {
+		return opts, err
+	}
+	opts.Nodes = nodes
+
+	if config.VRPFile != "" && config.RTRServer != "" {
+		return opts, fmt.Errorf("use --vrp ou --rtr, não ambos")
+	}
+
+	if config.RTRServer != "" {
+		client := rpki.NewRTRClient(config.RTRServer)
+		go client.Run(context.Background())
+		opts.VRPs = client
+	}
+
+	if config.VRPFile != "" {
+		vrps, err := rpki.LoadVRPFile(config.VRPFile)
+		if err != nil {
+			return opts, err
+		}
+		opts.VRPs = vrps
+	}
+
+	if config.ASPAFile != "" {
+		aspas, err := rpki.LoadASPAFile(config.ASPAFile)
+		if err != nil {
+			return opts, err
+		}
+		opts.ASPAs = aspas
+	}
+
+	if len(config.IRRFiles) > 0 {
+		db, err := irr.LoadDumps(config.IRRFiles)
+		if err != nil {
+			return opts, err
+		}
+		db.MaxDepth = config.ASSetDepth
+		opts.IRR = db
+	}
+
+	if len(config.LocalRegion) > 0 {
+		opts.LocalRegion = map[string]bool{}
+		for _, country := range config.LocalRegion {
+			opts.LocalRegion[strings.ToUpper(strings.TrimSpace(country))] = true
+		}
+	}
+
+	if config.CommunitiesFile != "" {
+		if err := opts.Communities.LoadFile(config.CommunitiesFile); err != nil {
+			return opts, err
+		}
+	}
+
+	if config.BogonsFile != "" {
+		if err := opts.Bogons.LoadFile(config.BogonsFile); err != nil {
+			return opts, err
+		}
+	}
+
+	if config.PolicyFile != "" {
+		rules, err := policy.LoadFile(config.PolicyFile)
+		if err != nil {
+			return opts, err
+		}
+		opts.Policy = rules
+	}
+
+	if len(config.IP2ASNFiles) > 0 {
+		table, err := ip2asn.LoadFiles(config.IP2ASNFiles)
+		if err != nil {
+			return opts, err
+		}
+		opts.IP2ASN = table
+	}
+
+	if config.ASRelFile != "" {
+		rels, err := asrel.LoadFile(config.ASRelFile)
+		if err != nil {
+			return opts, err
+		}
+		opts.Relationships = rels
+	}
+
+	return opts, nil
+}
+
+// loadNodes loads the RING node list given with --nodes, if any.
+func loadNodes() (*ring.NodeList, error) {
+	if config.NodesFile == "" {
+		return nil, nil
+	}
+	return ring.LoadNodes(config.NodesFile)
+}
+
 func Execute() {
 	rootCmd.Flags().BoolVarP(&showVersion, "version", "v", false, "mostra a versão do lg")
+	rootCmd.Flags().DurationVar(&config.RecentWindow, "recent", config.RecentWindow, "destaca rotas alteradas dentro desta janela (ex: 30m, 2h)")
+	rootCmd.PersistentFlags().StringVar(&config.NodesFile, "nodes", "", "cópia local da lista de nós do RING (JSON de api.ring.nlnog.net/1.0/nodes)")
+	rootCmd.Flags().StringVar(&config.VRPFile, "vrp", "", "arquivo de VRPs (JSON ou CSV do rpki-client/Routinator) para validação de origem local")
+	rootCmd.Flags().StringVar(&config.RTRServer, "rtr", "", "cache RTR (StayRTR/Routinator) para VRPs ao vivo (ex: localhost:8282)")
+	rootCmd.Flags().StringVar(&config.ASPAFile, "aspa", "", "objetos ASPA (JSON do rpki-client) para verificação local do AS path")
+	rootCmd.Flags().StringArrayVar(&config.IRRFiles, "irr", nil, "dump RPSL (RADB, RIPE, ARIN; texto ou .gz) para validação de route objects (repetível)")
+	rootCmd.Flags().IntVar(&config.ASSetDepth, "as-set-depth", config.ASSetDepth, "profundidade máxima ao expandir AS-SETs dos cones de clientes")
+	rootCmd.Flags().StringVar(&config.ASRelFile, "as-rel", "", "relações entre ASes no formato as-rel do CAIDA (p2c/p2p) para detectar route leaks")
+	rootCmd.Flags().StringArrayVar(&config.IP2ASNFiles, "ip2asn", nil, "tabela prefixo→origem (dump MRT TABLE_DUMP_V2 ou pfx2as do CAIDA; texto, .gz ou .bz2) (repetível)")
+	rootCmd.Flags().StringVar(&config.BogonsFile, "bogons", "", "arquivo com prefixos e ASNs adicionais a tratar como bogons")
+	rootCmd.Flags().StringVar(&config.PolicyFile, "policy", "", "declarações por prefixo, ex: \"192.0.2.0/24 origin=AS64500 upstream=AS3356,AS174 community=65000:100\"; origens não declaradas viram suspeitas de hijack")
+	rootCmd.Flags().StringSliceVar(&config.LocalRegion, "local-region", nil, "países da região local (ex: BR,AR,UY); caminhos entre peers e origens locais que saem dela são sinalizados")
+	rootCmd.Flags().StringVar(&config.CommunitiesFile, "communities", "", "dicionário de comunidades, ex: \"3356:9999 blackhole Lumen RTBH\"")
+	rootCmd.Flags().BoolVar(&jsonOutput, "json", false, "imprime o resultado das consultas em JSON, sem a interface interativa")
+	rootCmd.Flags().BoolVar(&checkOutput, "check", false, "imprime apenas os alertas e sai com código 2 se houver algum")
+	rootCmd.PersistentFlags().StringVar(&config.Resolver, "resolver", "", "servidor DNS usado para resolver hostnames (ex: 9.9.9.9:53)")
+	rootCmd.PersistentFlags().StringVar(&config.HostsFile, "hosts", "", "resolve hostnames a partir de um arquivo no formato hosts(5), sem DNS")
+	rootCmd.PersistentFlags().StringVar(&config.SourceTimezone, "lg-tz", config.SourceTimezone, "fuso horário do looking glass para datas sem fuso (ex: UTC, Europe/Amsterdam)")
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/drksbr/lg2/pkg/config"
+	"github.com/drksbr/lg2/pkg/ip2asn"
+	"github.com/spf13/cobra"
+)
+
+var ip2asnCmd = &cobra.Command{
+	Use:   "ip2asn [flags] IP|PREFIX...",
+	Short: "Look up the origin AS of addresses in a local prefix table",
+	Long: `Answers which AS originates an address or prefix without querying the looking
+glass, from prefix-to-origin tables built out of MRT RIB dumps (RouteViews,
+RIPE RIS) or CAIDA pfx2as files given with --table. The most specific prefix
+wins; prefixes with more than one origin are shown as MOAS.
+
+	lg ip2asn --table rib.20240101.0000.bz2 1.1.1.1 2001:db8::1
+	lg ip2asn --table routeviews-rv2-20240101-1200.pfx2as.gz 203.0.113.0/24`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runIP2ASN,
+}
+
+func init() {
+	ip2asnCmd.Flags().StringArrayVar(&config.IP2ASNFiles, "table", nil, "dump MRT ou pfx2as do CAIDA (texto, .gz ou .bz2) (repetível)")
+	rootCmd.AddCommand(ip2asnCmd)
+}
+
+func runIP2ASN(cmd *cobra.Command, args []string) error {
+	if len(config.IP2ASNFiles) == 0 {
+		return fmt.Errorf("informe ao menos uma tabela com --table")
+	}
+	table, err := ip2asn.LoadFiles(config.IP2ASNFiles)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "QUERY\tPREFIX\tORIGIN\t")
+	for _, arg := range args {
+		entry, found, err := lookupIP2ASN(table, arg)
+		if err != nil {
+			return err
+		}
+		if !found {
+			fmt.Fprintf(w, "%s\t-\tnot announced\t\n", arg)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t\n", arg, entry.Prefix, formatOrigins(entry.Origins))
+	}
+	return w.Flush()
+}
+
+// lookupIP2ASN looks up an address or a prefix.
+func lookupIP2ASN(table *ip2asn.Table, text string) (ip2asn.Entry, bool, error) {
+	text = strings.TrimSpace(text)
+	if strings.Contains(text, "/") {
+		prefix, err := netip.ParsePrefix(text)
+		if err != nil {
+			return ip2asn.Entry{}, false, fmt.Errorf("invalid prefix %q", text)
+		}
+		entry, found := table.LookupPrefix(prefix)
+		return entry, found, nil
+	}
+	addr, err := netip.ParseAddr(text)
+	if err != nil {
+		return ip2asn.Entry{}, false, fmt.Errorf("invalid address %q", text)
+	}
+	entry, found := table.Lookup(addr)
+	return entry, found, nil
+}
+
+// formatOrigins lists the origins of a prefix, marking MOAS.
+func formatOrigins(origins []uint32) string {
+	names := make([]string, len(origins))
+	for i, origin := range origins {
+		names[i] = fmt.Sprintf("AS%d", origin)
+	}
+	text := strings.Join(names, " ")
+	if len(origins) > 1 {
+		text += " (MOAS)"
+	}
+	return text
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/drksbr/lg2/pkg/fetch"
+	"github.com/drksbr/lg2/pkg/parser"
+	"github.com/drksbr/lg2/pkg/ring"
+)
+
+// queryResult holds the peers returned for one resolved query.
+type queryResult struct {
+	query parser.Query
+	peers []parser.Peer
+	err   error
+}
+
+// runQueries resolves every input and queries the looking glass for each
+// resulting prefix or address, enriching the peers from the node list.
+// Resolution errors are fatal; looking glass errors are kept per result.
+func runQueries(inputs []string, nodes *ring.NodeList) ([]queryResult, error) {
+	return runQueriesWith(inputs, nodes, fetch.QueryPeers)
+}
+
+// runQueriesWith is runQueries with another looking glass search, such as
+// fetch.QueryMoreSpecifics.
+func runQueriesWith(inputs []string, nodes *ring.NodeList, search func(parser.Query) ([]parser.Peer, error)) ([]queryResult, error) {
+	var results []queryResult
+	for _, input := range inputs {
+		queries, err := parser.ResolveQueries(input)
+		if err != nil {
+			return nil, err
+		}
+		for _, query := range queries {
+			peers, err := search(query)
+			nodes.EnrichPeers(peers)
+			results = append(results, queryResult{query: query, peers: peers, err: err})
+		}
+	}
+	return results, nil
+}
+
+// readPrefixList reads one prefix per line, ignoring blank lines and "#"
+// comments.
+func readPrefixList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open prefix list: %v", err)
+	}
+	defer f.Close()
+
+	var prefixes []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if line = strings.TrimSpace(line); line != "" {
+			prefixes = append(prefixes, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read prefix list: %v", err)
+	}
+	return prefixes, nil
+}
+
+// queryInputs combines the prefixes given as arguments with those in the
+// optional prefix list file.
+func queryInputs(args []string, listFile string) ([]string, error) {
+	inputs := append([]string{}, args...)
+	if listFile != "" {
+		prefixes, err := readPrefixList(listFile)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, prefixes...)
+	}
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("informe ao menos um prefixo (argumento ou --prefixes)")
+	}
+	return inputs, nil
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/drksbr/lg2/pkg/report"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes of the non-interactive mode.
+const (
+	exitOK    = 0
+	exitError = 1
+	exitAlert = 2 // Consultas concluídas com alertas
+)
+
+// rtrSyncTimeout bounds how long the non-interactive mode waits for the
+// first RTR sync before validating.
+const rtrSyncTimeout = 30 * time.Second
+
+// runReport queries every prefix given as argument and prints the report as
+// JSON (--json) and/or as alerts (--check). It returns the exit code.
+func runReport(cmd *cobra.Command, args []string, opts report.Options) int {
+	out, errOut := cmd.OutOrStdout(), cmd.ErrOrStderr()
+
+	inputs, err := queryInputs(args, "")
+	if err != nil {
+		fmt.Fprintln(errOut, err)
+		return exitError
+	}
+
+	// Live VRPs are only useful once the first sync is done
+	if feed, ok := opts.VRPs.(interface{ WaitSynced(context.Context) error }); ok {
+		ctx, cancel := context.WithTimeout(context.Background(), rtrSyncTimeout)
+		err := feed.WaitSynced(ctx)
+		cancel()
+		if err != nil {
+			fmt.Fprintf(errOut, "RTR cache not synced: %v\n", err)
+			return exitError
+		}
+	}
+
+	results, err := runQueries(inputs, opts.Nodes)
+	if err != nil {
+		fmt.Fprintln(errOut, err)
+		return exitError
+	}
+
+	var r report.Report
+	failed := 0
+	for _, result := range results {
+		r.Add(result.query, result.peers, result.err, opts)
+		if result.err != nil {
+			failed++
+		}
+	}
+
+	if jsonOutput {
+		if err := r.WriteJSON(out); err != nil {
+			fmt.Fprintln(errOut, err)
+			return exitError
+		}
+	}
+	if checkOutput {
+		alerts := out
+		if jsonOutput {
+			alerts = errOut
+		}
+		r.WriteAlerts(alerts)
+	}
+
+	switch {
+	case failed == len(results):
+		return exitError
+	case r.Critical() > 0:
+		// Blackhole, graceful shutdown and route leaks alert even without --check
+		return exitAlert
+	case checkOutput && r.Warnings() > 0:
+		return exitAlert
+	default:
+		return exitOK
+	}
+}
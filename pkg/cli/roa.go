@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/drksbr/lg2/pkg/fetch"
+	"github.com/drksbr/lg2/pkg/parser"
+	"github.com/drksbr/lg2/pkg/rpki"
+	"github.com/spf13/cobra"
+)
+
+var (
+	roaOrigins  []string
+	roaPrefixes string
+	roaSLURMOut string
+
+	roaCmd = &cobra.Command{
+		Use:   "roa-suggest [flags] [prefix...]",
+		Short: "Propose a minimal, safe ROA set from the routes seen by the peers",
+		Long: `Queries every prefix and the more-specifics of it the peers see, and proposes
+a minimal ROA set for the observed origins. maxLength is only raised above the
+prefix length when every more-specific up to it is announced (RFC 9319).
+Announcements the proposed ROAs would invalidate are listed as warnings.
+Addresses and hostnames are refused: give the prefixes you announce.
+
+	lg roa-suggest --asn AS65000 --prefixes ours.txt --slurm-out roas.slurm`,
+		RunE: runROASuggest,
+	}
+)
+
+func init() {
+	roaCmd.Flags().StringArrayVar(&roaOrigins, "asn", nil, "origem autorizada (repetível); sem --asn todas as origens observadas são incluídas")
+	roaCmd.Flags().StringVar(&roaPrefixes, "prefixes", "", "arquivo com um prefixo por linha")
+	roaCmd.Flags().StringVar(&roaSLURMOut, "slurm-out", "", "grava os ROAs propostos como asserções em um arquivo SLURM (RFC 8416)")
+	rootCmd.AddCommand(roaCmd)
+}
+
+func runROASuggest(cmd *cobra.Command, args []string) error {
+	inputs, err := queryInputs(args, roaPrefixes)
+	if err != nil {
+		return err
+	}
+
+	authorized := map[uint32]bool{}
+	for _, text := range roaOrigins {
+		asn, err := rpki.ParseASN(text)
+		if err != nil {
+			return err
+		}
+		authorized[asn] = true
+	}
+
+	// Addresses and hostnames are refused before any looking glass is queried
+	for _, input := range inputs {
+		query, err := parser.ParseQuery(input)
+		if err != nil || !query.Prefix.IsValid() {
+			return fmt.Errorf("%s: roa-suggest needs prefixes, not addresses or hostnames", input)
+		}
+	}
+
+	nodes, err := loadNodes()
+	if err != nil {
+		return err
+	}
+	results, err := runQueriesWith(inputs, nodes, fetch.QueryMoreSpecifics)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	var peers []parser.Peer
+	unknown := 0 // Rotas sem prefixo visível na página
+	for _, result := range results {
+		if result.err != nil {
+			fmt.Fprintf(out, "Warning: %s: %v\n", result.query, result.err)
+			continue
+		}
+		for _, peer := range result.peers {
+			if !peer.Prefix.IsValid() {
+				unknown++
+				continue
+			}
+			peers = append(peers, peer)
+		}
+	}
+	if unknown > 0 {
+		fmt.Fprintf(out, "Warning: %d routes without a visible prefix were skipped\n", unknown)
+	}
+
+	observations := rpki.Observe(peers)
+	rec := rpki.RecommendROAs(observations, authorized)
+
+	// Observed announcements
+	fmt.Fprintln(out, "Observed announcements:")
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  PREFIX\tORIGIN\tPEERS\t")
+	for _, obs := range observations {
+		origin := "none (AS_SET)"
+		if obs.HasOrigin {
+			origin = fmt.Sprintf("AS%d", obs.Origin)
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%d\t\n", obs.Prefix, origin, obs.Peers)
+	}
+	w.Flush()
+
+	// Proposed ROAs, in the fields RIR portals ask for
+	fmt.Fprintln(out, "\nProposed ROAs:")
+	w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  PREFIX\tORIGIN AS\tMAX LENGTH\t")
+	for _, roa := range rec.ROAs {
+		fmt.Fprintf(w, "  %s\tAS%d\t%d\t\n", roa.Prefix, roa.ASN, roa.MaxLength)
+	}
+	w.Flush()
+
+	for _, warning := range rec.Warnings {
+		fmt.Fprintf(out, "\nWarning: %s", warning)
+	}
+	for _, obs := range rec.Invalidated {
+		fmt.Fprintf(out, "\nWarning: %s seen by %d peers would become INVALID", obs, obs.Peers)
+	}
+	if len(rec.Warnings)+len(rec.Invalidated) > 0 {
+		fmt.Fprintln(out)
+	}
+
+	if roaSLURMOut != "" {
+		slurm := &rpki.SLURM{Assertions: rec.ROAs}
+		data, err := json.MarshalIndent(slurm, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(roaSLURMOut, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("failed to write SLURM file: %v", err)
+		}
+		fmt.Fprintf(out, "\nSLURM file written to %s\n", roaSLURMOut)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/drksbr/lg2/pkg/bogon"
+	"github.com/drksbr/lg2/pkg/config"
+	"github.com/drksbr/lg2/pkg/ip2asn"
+	"github.com/drksbr/lg2/pkg/parser"
+	"github.com/drksbr/lg2/pkg/traceroute"
+	"github.com/spf13/cobra"
+)
+
+var tracerouteCmd = &cobra.Command{
+	Use:   "traceroute [flags] FILE [prefix]",
+	Short: "Compare a traceroute with the AS paths seen by the peers",
+	Long: `Reads a traceroute in mtr --json, traceroute or tracepath format ("-" reads
+stdin), maps every hop to an ASN with the prefix tables given with --table and
+compares the data-plane AS sequence with the AS paths the looking glass peers
+report for the destination (or for the prefix given after the file).
+
+ASes of the closest BGP path missing from the traceroute (hidden, MPLS or
+unresponsive), ASes of the traceroute absent from every BGP path, IXP hops and
+an entry into the origin through an upstream no peer uses are highlighted.
+
+	mtr --json -c 1 example.com > trace.json
+	lg traceroute --table rib.bz2 trace.json
+	traceroute -n 192.0.2.1 | lg traceroute --table pfx2as.gz - 192.0.2.0/24`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runTraceroute,
+}
+
+func init() {
+	tracerouteCmd.Flags().StringArrayVar(&config.IP2ASNFiles, "table", nil, "dump MRT ou pfx2as do CAIDA (texto, .gz ou .bz2) (repetível)")
+	rootCmd.AddCommand(tracerouteCmd)
+}
+
+func runTraceroute(cmd *cobra.Command, args []string) error {
+	trace, err := readTrace(args[0])
+	if err != nil {
+		return err
+	}
+	table, err := ip2asn.LoadFiles(config.IP2ASNFiles)
+	if err != nil {
+		return err
+	}
+
+	target := trace.Destination
+	if trace.Target.IsValid() {
+		target = trace.Target.String()
+	}
+	if len(args) > 1 {
+		target = args[1]
+	}
+	if target == "" {
+		return fmt.Errorf("o traceroute não informa o destino; passe o prefixo depois do arquivo")
+	}
+
+	nodes, err := loadNodes()
+	if err != nil {
+		return err
+	}
+	results, err := runQueries([]string{target}, nodes)
+	if err != nil {
+		return err
+	}
+	var peers []parser.Peer
+	for _, result := range results {
+		if result.err != nil {
+			return fmt.Errorf("%s: %v", result.query, result.err)
+		}
+		peers = append(peers, result.peers...)
+	}
+
+	c := traceroute.Correlate(trace, peers, table, bogon.Default())
+	printCorrelation(cmd.OutOrStdout(), target, c)
+	return nil
+}
+
+// readTrace parses a traceroute file, or stdin for "-".
+func readTrace(path string) (*traceroute.Trace, error) {
+	if path == "-" {
+		return traceroute.Parse(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open traceroute: %v", err)
+	}
+	defer f.Close()
+	trace, err := traceroute.Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return trace, nil
+}
+
+// printCorrelation prints the mapped hops, both AS sequences and the
+// mismatches between them.
+func printCorrelation(out io.Writer, target string, c traceroute.Correlation) {
+	fmt.Fprintf(out, "Traceroute to %s, %d hops\n\n", target, len(c.Hops))
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  TTL\tADDRESS\tHOST\tASN\tPREFIX\tNOTE\t")
+	for _, hop := range c.Hops {
+		addr, host, asn, prefix := "*", "-", "-", "-"
+		if hop.Addr.IsValid() {
+			addr = hop.Addr.String()
+		}
+		if hop.Host != "" {
+			host = hop.Host
+		}
+		if hop.HasASN {
+			asn = fmt.Sprintf("AS%d", hop.ASN)
+		}
+		if hop.Prefix.IsValid() {
+			prefix = hop.Prefix.String()
+		}
+		note := ""
+		switch hop.Kind {
+		case traceroute.HopIXP:
+			note = "IXP"
+		case traceroute.HopPrivate:
+			note = "private"
+		case traceroute.HopUnannounced:
+			note = "not in table"
+		}
+		fmt.Fprintf(w, "  %d\t%s\t%s\t%s\t%s\t%s\t\n", hop.TTL, addr, host, asn, prefix, note)
+	}
+	w.Flush()
+
+	fmt.Fprintf(out, "\nData plane:    %s\n", asSequence(c.DataPlane))
+	if c.Peer == "" {
+		fmt.Fprintln(out, "Control plane: no peer has an AS path for the destination")
+		return
+	}
+	fmt.Fprintf(out, "Control plane: %s (closest: %s)\n", asSequence(c.ControlPlane), c.Peer)
+	if c.HasCommon {
+		fmt.Fprintf(out, "Diff:          %s (from AS%d, the first AS both paths cross)\n", c.Diff, c.Common)
+	} else {
+		fmt.Fprintln(out, "Diff:          the traceroute crosses no AS of the peers' paths")
+	}
+
+	mismatches := 0
+	warn := func(format string, a ...any) {
+		if mismatches == 0 {
+			fmt.Fprintln(out)
+		}
+		mismatches++
+		fmt.Fprintf(out, "Warning: "+format+"\n", a...)
+	}
+	if len(c.Hidden) > 0 {
+		warn("hidden ASNs %s: in the BGP path but not in the traceroute (MPLS, filtered ICMP or addresses numbered from a neighbour)", asList(c.Hidden))
+	}
+	if len(c.Extra) > 0 {
+		warn("ASNs %s in the traceroute are in no peer's AS path (third-party addresses or ASes hidden from BGP)", asList(c.Extra))
+	}
+	for _, hop := range c.IXPs {
+		name := hop.Host
+		if hop.Addr.IsValid() {
+			name = strings.TrimSpace(hop.Addr.String() + " " + hop.Host)
+		}
+		warn("hop %d %s is on an IXP peering LAN", hop.TTL, name)
+	}
+	if c.Asymmetric {
+		if c.Entry != 0 {
+			warn("the traceroute enters AS%d through AS%d, but the peers reach it through %s: the forward path does not follow what BGP shows", c.Origin, c.Entry, asList(c.Upstreams))
+		} else {
+			warn("the traceroute reaches the destination in AS%d, but the peers see AS%d as the origin", c.DataPlane[len(c.DataPlane)-1], c.Origin)
+		}
+	}
+	if mismatches == 0 {
+		fmt.Fprintln(out, "\nThe traceroute follows the control-plane path.")
+	}
+}
+
+// asSequence formats a path as "3356 174 64500".
+func asSequence(path []int) string {
+	if len(path) == 0 {
+		return "none"
+	}
+	parts := make([]string, len(path))
+	for i, asn := range path {
+		parts[i] = fmt.Sprintf("%d", asn)
+	}
+	return strings.Join(parts, " ")
+}
+
+// asList formats ASNs as "AS3356, AS174".
+func asList(asns []int) string {
+	parts := make([]string, len(asns))
+	for i, asn := range asns {
+		parts[i] = fmt.Sprintf("AS%d", asn)
+	}
+	return strings.Join(parts, ", ")
+}
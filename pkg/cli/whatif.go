@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/drksbr/lg2/pkg/config"
+	"github.com/drksbr/lg2/pkg/rpki"
+	"github.com/spf13/cobra"
+)
+
+var (
+	whatIfAdd      []string
+	whatIfRemove   []string
+	whatIfSLURM    string
+	whatIfPrefixes string
+	whatIfAll      bool
+
+	whatIfCmd = &cobra.Command{
+		Use:   "whatif [flags] [prefix...]",
+		Short: "Simulate the impact of ROA changes on the routes seen by every peer",
+		Long: `Recomputes origin validation for every peer's route with a candidate VRP set
+(current VRPs plus/minus proposed ROAs, or with a SLURM file applied) and lists
+the routes whose validation state would change.
+
+	lg whatif --vrp vrps.json --add "192.0.2.0/24-24 AS65000" 192.0.2.0/24
+	lg whatif --vrp vrps.json --slurm proposed.slurm --prefixes ours.txt`,
+		RunE: runWhatIf,
+	}
+)
+
+func init() {
+	whatIfCmd.Flags().StringVar(&config.VRPFile, "vrp", "", "VRPs atuais (JSON ou CSV do rpki-client/Routinator)")
+	whatIfCmd.Flags().StringArrayVar(&whatIfAdd, "add", nil, "ROA proposto, ex: \"192.0.2.0/24-24 AS65000\" (repetível)")
+	whatIfCmd.Flags().StringArrayVar(&whatIfRemove, "remove", nil, "ROA a remover (mesmo prefixo e ASN, repetível)")
+	whatIfCmd.Flags().StringVar(&whatIfSLURM, "slurm", "", "arquivo SLURM (RFC 8416) aplicado às VRPs atuais")
+	whatIfCmd.Flags().StringVar(&whatIfPrefixes, "prefixes", "", "arquivo com um prefixo por linha")
+	whatIfCmd.Flags().BoolVar(&whatIfAll, "all", false, "lista também as rotas que não mudam")
+	rootCmd.AddCommand(whatIfCmd)
+}
+
+func runWhatIf(cmd *cobra.Command, args []string) error {
+	inputs, err := queryInputs(args, whatIfPrefixes)
+	if err != nil {
+		return err
+	}
+
+	// Current and candidate VRP sets
+	current := rpki.NewVRPSet(nil)
+	if config.VRPFile != "" {
+		if current, err = rpki.LoadVRPFile(config.VRPFile); err != nil {
+			return err
+		}
+	}
+	add, err := parseVRPs(whatIfAdd)
+	if err != nil {
+		return err
+	}
+	remove, err := parseVRPs(whatIfRemove)
+	if err != nil {
+		return err
+	}
+	var slurm *rpki.SLURM
+	if whatIfSLURM != "" {
+		if slurm, err = rpki.LoadSLURMFile(whatIfSLURM); err != nil {
+			return err
+		}
+	}
+	if len(add) == 0 && len(remove) == 0 && slurm == nil {
+		return fmt.Errorf("nenhuma mudança proposta: use --add, --remove ou --slurm")
+	}
+	candidate := rpki.Candidate(current, add, remove, slurm)
+
+	nodes, err := loadNodes()
+	if err != nil {
+		return err
+	}
+	results, err := runQueries(inputs, nodes)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	var total rpki.SimulationSummary
+	for _, result := range results {
+		if result.err != nil {
+			fmt.Fprintf(out, "%s: %v\n\n", result.query, result.err)
+			continue
+		}
+		changes, summary := rpki.Simulate(current, candidate, result.peers)
+		printSimulation(out, result.query.String(), changes, summary)
+		total.Routes += summary.Routes
+		total.ToInvalid += summary.ToInvalid
+		total.ToValid += summary.ToValid
+		total.ToNotFound += summary.ToNotFound
+		total.Unchanged += summary.Unchanged
+	}
+
+	if len(results) > 1 {
+		fmt.Fprintf(out, "Total: %d routes, %d become invalid, %d become valid, %d become not-found, %d unchanged\n",
+			total.Routes, total.ToInvalid, total.ToValid, total.ToNotFound, total.Unchanged)
+	}
+	return nil
+}
+
+// printSimulation prints the routes of one prefix whose state would change.
+func printSimulation(out io.Writer, prefix string, changes []rpki.RouteChange, summary rpki.SimulationSummary) {
+	fmt.Fprintf(out, "Prefix %s (%d peers)\n", prefix, summary.Routes)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  PEER\tORIGIN\tBEFORE\tAFTER\t")
+	for _, change := range changes {
+		if !change.Flipped() && !whatIfAll {
+			continue
+		}
+		origin := "none"
+		if change.Origin != 0 {
+			origin = fmt.Sprintf("AS%d", change.Origin)
+		}
+		marker := ""
+		if change.Flipped() {
+			marker = "*"
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", change.Peer.PeerName, origin, change.Before, change.After, marker)
+	}
+	w.Flush()
+
+	fmt.Fprintf(out, "  %d become invalid, %d become valid, %d become not-found, %d unchanged\n\n",
+		summary.ToInvalid, summary.ToValid, summary.ToNotFound, summary.Unchanged)
+}
+
+// parseVRPs parses ROAs given on the command line.
+func parseVRPs(texts []string) ([]rpki.VRP, error) {
+	vrps := make([]rpki.VRP, 0, len(texts))
+	for _, text := range texts {
+		vrp, err := rpki.ParseVRP(text)
+		if err != nil {
+			return nil, err
+		}
+		vrps = append(vrps, vrp)
+	}
+	return vrps, nil
+}
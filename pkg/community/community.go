@@ -0,0 +1,139 @@
+// Package community names BGP communities from a dictionary and detects the
+// ones that need attention, such as blackhole and graceful shutdown.
+package community
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/drksbr/lg2/pkg/parser"
+)
+
+// Kinds with a meaning for lg; other kinds in a dictionary only name the
+// community.
+const (
+	KindBlackhole        = "blackhole"
+	KindGracefulShutdown = "graceful-shutdown"
+)
+
+// Entry is a community pattern and its meaning. Any field of the pattern
+// may be "*".
+type Entry struct {
+	Pattern     string
+	Kind        string
+	Description string
+}
+
+// Matches reports whether the normalized community matches the pattern.
+func (e Entry) Matches(community string) bool {
+	want := strings.Split(e.Pattern, ":")
+	got := strings.Split(community, ":")
+	if len(want) != len(got) {
+		return false
+	}
+	for i := range want {
+		if want[i] != "*" && want[i] != got[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Dictionary holds the known communities.
+type Dictionary struct {
+	Entries []Entry
+}
+
+// Default returns the well-known communities.
+func Default() *Dictionary {
+	return &Dictionary{Entries: []Entry{
+		{"65535:666", KindBlackhole, "BLACKHOLE (RFC 7999)"},
+		{"65535:0", KindGracefulShutdown, "GRACEFUL_SHUTDOWN (RFC 8326)"},
+		{"65535:1", "well-known", "ACCEPT_OWN (RFC 7611)"},
+		{"65535:65281", "well-known", "NO_EXPORT (RFC 1997)"},
+		{"65535:65282", "well-known", "NO_ADVERTISE (RFC 1997)"},
+		{"65535:65283", "well-known", "NO_EXPORT_SUBCONFED (RFC 1997)"},
+		{"65535:65284", "well-known", "NOPEER (RFC 3765)"},
+	}}
+}
+
+// LoadFile adds the entries of a dictionary file. Each line holds a
+// community pattern, a kind and an optional description; "#" starts a
+// comment:
+//
+//	3356:9999   blackhole  Lumen remote triggered blackhole
+//	64500:*:1   info       learned in Amsterdam
+func (d *Dictionary) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open community dictionary: %v", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 {
+			return fmt.Errorf("%s: line %d: expected \"community kind [description]\"", path, line)
+		}
+		pattern := Normalize(fields[0])
+		if parts := strings.Count(pattern, ":"); parts < 1 || parts > 2 {
+			return fmt.Errorf("%s: line %d: invalid community %q", path, line, fields[0])
+		}
+		// Entries from the file take precedence over the defaults
+		d.Entries = append([]Entry{{pattern, strings.ToLower(fields[1]), strings.Join(fields[2:], " ")}}, d.Entries...)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+// Normalize converts the looking glass notation of standard and large
+// communities ("(64500, 1)", "(64500, 1, 2)") to "64500:1" and "64500:1:2".
+func Normalize(text string) string {
+	text = strings.Trim(strings.TrimSpace(text), "()")
+	text = strings.ReplaceAll(text, " ", "")
+	return strings.ReplaceAll(text, ",", ":")
+}
+
+// Lookup returns the first entry matching the community.
+func (d *Dictionary) Lookup(community string) (Entry, bool) {
+	if d == nil {
+		return Entry{}, false
+	}
+	community = Normalize(community)
+	for _, entry := range d.Entries {
+		if entry.Matches(community) {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// Match is a community of a route found in the dictionary.
+type Match struct {
+	Community string
+	Entry     Entry
+}
+
+// Alerts returns the blackhole and graceful shutdown communities of the
+// peer's route.
+func (d *Dictionary) Alerts(peer *parser.Peer) []Match {
+	var matches []Match
+	for _, community := range peer.Communities {
+		entry, ok := d.Lookup(community)
+		if ok && (entry.Kind == KindBlackhole || entry.Kind == KindGracefulShutdown) {
+			matches = append(matches, Match{Community: Normalize(community), Entry: entry})
+		}
+	}
+	return matches
+}
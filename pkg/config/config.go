@@ -1,7 +1,41 @@
 package config
 
+import "time"
+
 var (
 	Version         = "0.0.4"
 	Debug      bool = false
 	SaveSample bool = true
+
+	// Janela em que uma rota é destacada como alterada recentemente
+	RecentWindow = time.Hour
+	// Fuso horário do looking glass para datas sem fuso explícito
+	SourceTimezone = "UTC"
+	// Cópia local da lista de nós do RING
+	NodesFile string
+	// Arquivo de VRPs para validação de origem local
+	VRPFile string
+	// Cache RTR para VRPs ao vivo
+	RTRServer string
+	// Objetos ASPA para verificação de caminho
+	ASPAFile string
+	// Dumps RPSL (RADB, RIPE, ARIN...) para validação de route objects
+	IRRFiles []string
+	// Bogons adicionais definidos pelo usuário
+	BogonsFile string
+	// Declarações por prefixo (origens esperadas)
+	PolicyFile string
+	// Países da região local (códigos ISO), para detectar caminhos que saem dela
+	LocalRegion []string
+	// Dicionário de comunidades (blackhole de provedores etc.)
+	CommunitiesFile string
+	// Tabelas prefixo→origem (dump MRT ou pfx2as) para ip2asn offline
+	IP2ASNFiles []string
+	// Relações entre ASes (formato as-rel do CAIDA)
+	ASRelFile string
+	// Profundidade máxima ao expandir AS-SETs
+	ASSetDepth = 10
+	// Servidor DNS e arquivo hosts usados para resolver hostnames
+	Resolver  string
+	HostsFile string
 )
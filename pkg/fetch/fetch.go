@@ -5,8 +5,17 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/netip"
 	"os"
 	"strings"
+
+	"github.com/drksbr/lg2/pkg/parser"
+)
+
+// Match modes of the looking glass prefix search.
+const (
+	MatchExact    = "exact"    // Somente o prefixo consultado
+	MatchOrLonger = "orlonger" // O prefixo e seus more-specifics
 )
 
 var (
@@ -34,13 +43,13 @@ func saveHTML(htmlData string, query string) {
 }
 
 // GetLookingGlassData makes an HTTP request to the Looking Glass.
-func GetLookingGlassData(query string) (string, error) {
+func GetLookingGlassData(query string, match string) (string, error) {
 	if debug {
 		url = "http://localhost:3000/sample"
 	}
 
 	client := &http.Client{}
-	reqURL := url + "?q=" + query + "&match=exact&peer=all"
+	reqURL := url + "?q=" + query + "&match=" + match + "&peer=all"
 	req, err := http.NewRequest("GET", reqURL, nil)
 	if err != nil {
 		return "", err
@@ -96,3 +105,37 @@ func GetLookingGlassData(query string) (string, error) {
 
 	return string(body), nil
 }
+
+// QueryPeers queries the Looking Glass for a resolved query and parses the
+// peers in the response.
+func QueryPeers(query parser.Query) ([]parser.Peer, error) {
+	return queryPeers(query, MatchExact, query.Prefix)
+}
+
+// QueryMoreSpecifics queries the Looking Glass for a prefix and every
+// more-specific of it. Routes whose prefix the page does not show are
+// returned with an invalid Prefix.
+func QueryMoreSpecifics(query parser.Query) ([]parser.Peer, error) {
+	if !query.Prefix.IsValid() {
+		return nil, fmt.Errorf("%s is an address, not a prefix", query)
+	}
+	return queryPeers(query, MatchOrLonger, netip.Prefix{})
+}
+
+// queryPeers fetches and parses one looking glass search.
+func queryPeers(query parser.Query, match string, queried netip.Prefix) ([]parser.Peer, error) {
+	data, err := GetLookingGlassData(query.LookingGlassQuery(), match)
+	if err != nil {
+		return nil, err
+	}
+
+	peers, err := parser.ParseHTML(data, queried)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(peers) == 0 {
+		return nil, fmt.Errorf("no peers found for %s", query)
+	}
+	return peers, nil
+}
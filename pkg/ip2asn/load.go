@@ -0,0 +1,116 @@
+package ip2asn
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LoadFiles builds one table from several files, MRT RIB dumps and pfx2as
+// files alike.
+func LoadFiles(paths []string) (*Table, error) {
+	table := NewTable()
+	for _, path := range paths {
+		if err := table.LoadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	return table, nil
+}
+
+// LoadFile adds the prefixes of an MRT RIB dump (TABLE_DUMP or
+// TABLE_DUMP_V2, as published by RouteViews and RIPE RIS) or of a pfx2as
+// file. The format is detected from the content; plain, gzip and bzip2
+// files are accepted.
+func (t *Table) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open prefix table: %v", err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	var r io.Reader = reader
+	magic, _ := reader.Peek(3)
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	case bytes.Equal(magic, []byte("BZh")):
+		r = bzip2.NewReader(reader)
+	}
+
+	content := bufio.NewReader(r)
+	if isMRT(content) {
+		err = t.ReadMRT(content)
+	} else {
+		err = t.ReadPfx2as(content)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+// isMRT reports whether the content starts with an MRT TABLE_DUMP or
+// TABLE_DUMP_V2 header. Text files never have a zero byte there.
+func isMRT(r *bufio.Reader) bool {
+	header, err := r.Peek(mrtHeaderLength)
+	if err != nil {
+		return false
+	}
+	kind := uint16(header[4])<<8 | uint16(header[5])
+	return kind == mrtTableDump || kind == mrtTableDumpV2
+}
+
+// ReadPfx2as reads a CAIDA pfx2as file ("1.0.0.0	24	13335"). A prefix
+// may also be written as "1.0.0.0/24 13335". Origins separated by "_" are
+// MOAS; those separated by "," are an AS_SET and are all kept.
+func (t *Table) ReadPfx2as(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		var prefixText, originText string
+		switch {
+		case len(fields) >= 3 && !strings.Contains(fields[0], "/"):
+			prefixText, originText = fields[0]+"/"+fields[1], fields[2]
+		case len(fields) >= 2:
+			prefixText, originText = fields[0], fields[1]
+		default:
+			return fmt.Errorf("line %d: expected \"prefix length origin\"", line)
+		}
+
+		prefix, err := netip.ParsePrefix(prefixText)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid prefix %q", line, prefixText)
+		}
+		var origins []uint32
+		for _, field := range strings.FieldsFunc(originText, func(r rune) bool { return r == '_' || r == ',' }) {
+			asn, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(field), "AS"), 10, 32)
+			if err != nil {
+				return fmt.Errorf("line %d: invalid origin %q", line, field)
+			}
+			origins = append(origins, uint32(asn))
+		}
+		t.Insert(prefix, origins...)
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,216 @@
+package ip2asn
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+)
+
+// MRT record types and subtypes (RFC 6396, RFC 8050).
+const (
+	mrtHeaderLength = 12
+
+	mrtTableDump   = 12
+	mrtTableDumpV2 = 13
+
+	// TABLE_DUMP subtypes
+	tableDumpIPv4 = 1
+	tableDumpIPv6 = 2
+
+	// TABLE_DUMP_V2 subtypes
+	ribIPv4Unicast        = 2
+	ribIPv6Unicast        = 4
+	ribIPv4UnicastAddPath = 8
+	ribIPv6UnicastAddPath = 10
+
+	// BGP path attributes
+	attrExtendedLength = 0x10
+	attrASPath         = 2
+	attrAS4Path        = 17
+
+	segmentSequence = 2
+)
+
+var errTruncated = errors.New("truncated MRT record")
+
+// ReadMRT reads the RIB entries of an MRT dump and records the origin each
+// peer sees for every prefix. Other record types are skipped.
+func (t *Table) ReadMRT(r io.Reader) error {
+	header := make([]byte, mrtHeaderLength)
+	for record := 1; ; record++ {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("record %d: %v", record, errTruncated)
+		}
+		kind := binary.BigEndian.Uint16(header[4:6])
+		subtype := binary.BigEndian.Uint16(header[6:8])
+		body := make([]byte, binary.BigEndian.Uint32(header[8:12]))
+		if _, err := io.ReadFull(r, body); err != nil {
+			return fmt.Errorf("record %d: %v", record, errTruncated)
+		}
+
+		var err error
+		switch kind {
+		case mrtTableDump:
+			err = t.readTableDump(subtype, body)
+		case mrtTableDumpV2:
+			err = t.readRIB(subtype, body)
+		}
+		if err != nil {
+			return fmt.Errorf("record %d: %v", record, err)
+		}
+	}
+}
+
+// readRIB reads a TABLE_DUMP_V2 RIB record: one prefix and the route of
+// every peer that has it.
+func (t *Table) readRIB(subtype uint16, body []byte) error {
+	var family int
+	addPath := false
+	switch subtype {
+	case ribIPv4Unicast:
+		family = 4
+	case ribIPv6Unicast:
+		family = 16
+	case ribIPv4UnicastAddPath:
+		family, addPath = 4, true
+	case ribIPv6UnicastAddPath:
+		family, addPath = 16, true
+	default:
+		return nil // PEER_INDEX_TABLE, multicast e afins
+	}
+
+	if len(body) < 5 {
+		return errTruncated
+	}
+	bits := int(body[4])
+	size := (bits + 7) / 8
+	if bits > family*8 || len(body) < 5+size+2 {
+		return errTruncated
+	}
+	raw := make([]byte, family)
+	copy(raw, body[5:5+size])
+	addr, _ := netip.AddrFromSlice(raw)
+	prefix := netip.PrefixFrom(addr, bits)
+
+	rest := body[5+size:]
+	count := int(binary.BigEndian.Uint16(rest))
+	rest = rest[2:]
+	var origins []uint32
+	for i := 0; i < count; i++ {
+		skip := 6 // peer index, originated time
+		if addPath {
+			skip += 4 // path identifier
+		}
+		if len(rest) < skip+2 {
+			return errTruncated
+		}
+		length := int(binary.BigEndian.Uint16(rest[skip:]))
+		rest = rest[skip+2:]
+		if len(rest) < length {
+			return errTruncated
+		}
+		if origin, ok := pathOrigin(rest[:length], 4); ok {
+			origins = append(origins, origin)
+		}
+		rest = rest[length:]
+	}
+	if len(origins) > 0 {
+		t.Insert(prefix, origins...)
+	}
+	return nil
+}
+
+// readTableDump reads a legacy TABLE_DUMP record, with 2-byte ASNs in
+// AS_PATH and 4-byte ones in AS4_PATH.
+func (t *Table) readTableDump(subtype uint16, body []byte) error {
+	var family int
+	switch subtype {
+	case tableDumpIPv4:
+		family = 4
+	case tableDumpIPv6:
+		family = 16
+	default:
+		return nil
+	}
+
+	// view, sequence, prefix, length, status, originated time, peer IP, peer AS, attribute length
+	fixed := 4 + family + 1 + 1 + 4 + family + 2 + 2
+	if len(body) < fixed {
+		return errTruncated
+	}
+	addr, _ := netip.AddrFromSlice(body[4 : 4+family])
+	bits := int(body[4+family])
+	if bits > family*8 {
+		return errTruncated
+	}
+	length := int(binary.BigEndian.Uint16(body[fixed-2:]))
+	if len(body) < fixed+length {
+		return errTruncated
+	}
+	if origin, ok := pathOrigin(body[fixed:fixed+length], 2); ok {
+		t.Insert(netip.PrefixFrom(addr, bits), origin)
+	}
+	return nil
+}
+
+// pathOrigin finds the origin in the path attributes: the last ASN of the
+// path when it ends in an AS_SEQUENCE. AS4_PATH wins over AS_PATH.
+func pathOrigin(attrs []byte, asnSize int) (uint32, bool) {
+	var origin, origin4 uint32
+	var ok, ok4 bool
+	for len(attrs) >= 3 {
+		flags, kind := attrs[0], attrs[1]
+		header, length := 3, int(attrs[2])
+		if flags&attrExtendedLength != 0 {
+			if len(attrs) < 4 {
+				break
+			}
+			header, length = 4, int(binary.BigEndian.Uint16(attrs[2:4]))
+		}
+		if len(attrs) < header+length {
+			break
+		}
+		value := attrs[header : header+length]
+		switch kind {
+		case attrASPath:
+			origin, ok = lastASN(value, asnSize)
+		case attrAS4Path:
+			origin4, ok4 = lastASN(value, 4)
+		}
+		attrs = attrs[header+length:]
+	}
+	if ok4 {
+		return origin4, true
+	}
+	return origin, ok
+}
+
+// lastASN returns the last ASN of an encoded AS_PATH when its last segment
+// is an AS_SEQUENCE.
+func lastASN(path []byte, asnSize int) (uint32, bool) {
+	var last uint32
+	ok := false
+	for len(path) >= 2 {
+		kind, count := path[0], int(path[1])
+		size := count * asnSize
+		if len(path) < 2+size {
+			return 0, false
+		}
+		ok = kind == segmentSequence && count > 0
+		if ok {
+			end := path[2+size-asnSize : 2+size]
+			if asnSize == 2 {
+				last = uint32(binary.BigEndian.Uint16(end))
+			} else {
+				last = binary.BigEndian.Uint32(end)
+			}
+		}
+		path = path[2+size:]
+	}
+	return last, ok
+}
@@ -0,0 +1,155 @@
+// Package ip2asn maps addresses to the ASes originating them, from a local
+// prefix-to-origin table built out of an MRT RIB dump or a pfx2as file.
+package ip2asn
+
+import (
+	"net/netip"
+	"slices"
+)
+
+// Entry is a prefix and the ASes seen originating it. More than one origin
+// means the prefix is MOAS.
+type Entry struct {
+	Prefix  netip.Prefix
+	Origins []uint32
+}
+
+// node is a node of the binary radix tree, one bit per level.
+type node struct {
+	children [2]*node
+	entry    *Entry
+}
+
+// Table is a longest-prefix-match table of origins. Lookups are safe
+// concurrently once loading is done.
+type Table struct {
+	v4, v6 *node
+	count  int
+}
+
+// NewTable returns an empty table.
+func NewTable() *Table {
+	return &Table{v4: &node{}, v6: &node{}}
+}
+
+// Len returns the number of prefixes in the table.
+func (t *Table) Len() int {
+	if t == nil {
+		return 0
+	}
+	return t.count
+}
+
+// root returns the tree of the address family.
+func (t *Table) root(addr netip.Addr) *node {
+	if addr.Is4() {
+		return t.v4
+	}
+	return t.v6
+}
+
+// Insert adds origins to a prefix, merging them with those already known.
+func (t *Table) Insert(prefix netip.Prefix, origins ...uint32) {
+	prefix = normalize(prefix)
+	n := t.root(prefix.Addr())
+	bytes := prefix.Addr().AsSlice()
+	for i := 0; i < prefix.Bits(); i++ {
+		b := bit(bytes, i)
+		if n.children[b] == nil {
+			n.children[b] = &node{}
+		}
+		n = n.children[b]
+	}
+	if n.entry == nil {
+		n.entry = &Entry{Prefix: prefix}
+		t.count++
+	}
+	for _, origin := range origins {
+		if !slices.Contains(n.entry.Origins, origin) {
+			n.entry.Origins = append(n.entry.Origins, origin)
+		}
+	}
+	slices.Sort(n.entry.Origins)
+}
+
+// Lookup returns the most specific prefix containing the address.
+func (t *Table) Lookup(addr netip.Addr) (Entry, bool) {
+	if t == nil || !addr.IsValid() {
+		return Entry{}, false
+	}
+	addr = addr.Unmap()
+	return t.longestMatch(addr, addr.BitLen())
+}
+
+// LookupPrefix returns the most specific prefix covering the given one,
+// which may be the prefix itself.
+func (t *Table) LookupPrefix(prefix netip.Prefix) (Entry, bool) {
+	if t == nil || !prefix.IsValid() {
+		return Entry{}, false
+	}
+	prefix = normalize(prefix)
+	return t.longestMatch(prefix.Addr(), prefix.Bits())
+}
+
+// Origins returns the origins of the most specific prefix containing the
+// address, or nil.
+func (t *Table) Origins(addr netip.Addr) []uint32 {
+	entry, _ := t.Lookup(addr)
+	return entry.Origins
+}
+
+// longestMatch walks the first bits of addr and keeps the deepest entry.
+func (t *Table) longestMatch(addr netip.Addr, bits int) (Entry, bool) {
+	var best *Entry
+	n := t.root(addr)
+	bytes := addr.AsSlice()
+	for i := 0; n != nil; i++ {
+		if n.entry != nil {
+			best = n.entry
+		}
+		if i == bits {
+			break
+		}
+		n = n.children[bit(bytes, i)]
+	}
+	if best == nil {
+		return Entry{}, false
+	}
+	return *best, true
+}
+
+// Walk calls fn for every prefix, IPv4 first, in address order.
+func (t *Table) Walk(fn func(Entry)) {
+	if t == nil {
+		return
+	}
+	var walk func(n *node)
+	walk = func(n *node) {
+		if n == nil {
+			return
+		}
+		if n.entry != nil {
+			fn(*n.entry)
+		}
+		walk(n.children[0])
+		walk(n.children[1])
+	}
+	walk(t.v4)
+	walk(t.v6)
+}
+
+// normalize unmaps IPv4-mapped prefixes and clears the host bits.
+func normalize(prefix netip.Prefix) netip.Prefix {
+	addr := prefix.Addr()
+	bits := prefix.Bits()
+	if addr.Is4In6() {
+		addr = addr.Unmap()
+		bits = max(0, bits-96)
+	}
+	return netip.PrefixFrom(addr, bits).Masked()
+}
+
+// bit returns the i-th most significant bit of the address bytes.
+func bit(bytes []byte, i int) int {
+	return int(bytes[i/8]>>(7-i%8)) & 1
+}
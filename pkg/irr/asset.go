@@ -0,0 +1,262 @@
+package irr
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/drksbr/lg2/pkg/analysis"
+	"github.com/drksbr/lg2/pkg/parser"
+	"github.com/drksbr/lg2/pkg/rpki"
+)
+
+// DefaultMaxDepth bounds AS-SET nesting when no limit is given.
+const DefaultMaxDepth = 10
+
+// ASSet is an as-set object. When several dumps define the same set, the
+// first one loaded wins.
+type ASSet struct {
+	Name    string
+	Members []string // ASNs e AS-SETs, como declarados
+	Source  string
+}
+
+// Expansion is the recursive expansion of one or more AS-SETs.
+type Expansion struct {
+	Name      string
+	ASNs      []uint32
+	Sets      []string // AS-SETs visitados
+	Loops     []string // Ciclos encontrados, ex: "AS-A → AS-B → AS-A"
+	Missing   []string // AS-SETs sem objeto nos dumps
+	Truncated []string // AS-SETs ignorados pelo limite de profundidade
+
+	members map[uint32]bool
+}
+
+// Contains reports whether the ASN is part of the expansion.
+func (e *Expansion) Contains(asn uint32) bool {
+	return e.members[asn]
+}
+
+// setName normalises an AS-SET reference, dropping an RPSL "SOURCE::"
+// prefix.
+func setName(text string) string {
+	text = strings.ToUpper(strings.TrimSpace(text))
+	if _, name, ok := strings.Cut(text, "::"); ok {
+		text = name
+	}
+	return text
+}
+
+// isSetName reports whether text names an AS-SET ("AS-FOO", "AS65000:AS-FOO").
+func isSetName(text string) bool {
+	for _, part := range strings.Split(setName(text), ":") {
+		if strings.HasPrefix(part, "AS-") {
+			return true
+		}
+	}
+	return false
+}
+
+// splitMembers splits a members: value on commas and whitespace.
+func splitMembers(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+}
+
+func (db *Database) addASSet(obj *Object, source string) {
+	name := setName(obj.Key)
+	if _, exists := db.asSets[name]; exists {
+		return
+	}
+	set := ASSet{Name: name, Source: source}
+	for _, attr := range []string{"members", "mp-members"} {
+		for _, value := range obj.Get(attr) {
+			set.Members = append(set.Members, splitMembers(value)...)
+		}
+	}
+	db.asSets[name] = set
+}
+
+// addAutNum records what an aut-num says it announces in its export and
+// mp-export policies, e.g. "to AS-ANY announce AS-EXAMPLE". Exports of ANY
+// (usually towards customers) say nothing about the cone and are skipped.
+func (db *Database) addAutNum(obj *Object) {
+	asn, err := rpki.ParseASN(obj.Key)
+	if err != nil {
+		return
+	}
+	seen := map[string]bool{}
+	for _, term := range db.announce[asn] {
+		seen[term] = true
+	}
+	for _, attr := range []string{"export", "mp-export"} {
+		for _, value := range obj.Get(attr) {
+			for _, term := range announceTerms(value) {
+				if !seen[term] {
+					seen[term] = true
+					db.announce[asn] = append(db.announce[asn], term)
+				}
+			}
+		}
+	}
+}
+
+// announceTerms extracts the ASNs and AS-SETs after "announce" in a policy.
+func announceTerms(policy string) []string {
+	fields := strings.Fields(strings.NewReplacer("{", " ", "}", " ", ";", " ; ").Replace(policy))
+	var terms []string
+	announcing := false
+	for _, field := range fields {
+		upper := strings.ToUpper(field)
+		switch {
+		case upper == "ANNOUNCE":
+			announcing = true
+		case !announcing:
+		case upper == ";" || upper == "ACTION" || upper == "REFINE" || upper == "EXCEPT":
+			announcing = false
+		case upper == "ANY" || upper == "AS-ANY":
+		case isSetName(upper):
+			terms = append(terms, setName(upper))
+		default:
+			if _, err := rpki.ParseASN(upper); err == nil {
+				terms = append(terms, upper)
+			}
+		}
+	}
+	return terms
+}
+
+// ASSet returns the as-set object with the given name.
+func (db *Database) ASSet(name string) (ASSet, bool) {
+	if db == nil {
+		return ASSet{}, false
+	}
+	set, ok := db.asSets[setName(name)]
+	return set, ok
+}
+
+// Expand expands AS-SETs recursively, following at most maxDepth levels of
+// nesting. Loops and undefined sets are recorded instead of failing.
+func (db *Database) Expand(name string, maxDepth int) *Expansion {
+	return db.expandTerms(setName(name), []string{name}, maxDepth)
+}
+
+func (db *Database) expandTerms(name string, terms []string, maxDepth int) *Expansion {
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxDepth
+	}
+	e := &expander{db: db, maxDepth: maxDepth, done: map[string]bool{}}
+	e.result = &Expansion{Name: name, members: map[uint32]bool{}}
+	e.members(terms, nil)
+
+	for asn := range e.result.members {
+		e.result.ASNs = append(e.result.ASNs, asn)
+	}
+	sort.Slice(e.result.ASNs, func(i, j int) bool { return e.result.ASNs[i] < e.result.ASNs[j] })
+	sort.Strings(e.result.Sets)
+	return e.result
+}
+
+type expander struct {
+	db       *Database
+	maxDepth int
+	done     map[string]bool
+	result   *Expansion
+}
+
+func (e *expander) members(terms []string, stack []string) {
+	for _, term := range terms {
+		if isSetName(term) {
+			e.visit(setName(term), stack)
+			continue
+		}
+		if asn, err := rpki.ParseASN(term); err == nil {
+			e.result.members[asn] = true
+		}
+	}
+}
+
+func (e *expander) visit(name string, stack []string) {
+	for i, open := range stack {
+		if open == name {
+			loop := append(append([]string{}, stack[i:]...), name)
+			e.result.Loops = append(e.result.Loops, strings.Join(loop, " → "))
+			return
+		}
+	}
+	if e.done[name] {
+		return
+	}
+	if len(stack) >= e.maxDepth {
+		e.result.Truncated = append(e.result.Truncated, name)
+		return
+	}
+	set, ok := e.db.asSets[name]
+	if !ok {
+		e.done[name] = true
+		e.result.Missing = append(e.result.Missing, name)
+		return
+	}
+	e.done[name] = true
+	e.result.Sets = append(e.result.Sets, name)
+	e.members(set.Members, append(stack, name))
+}
+
+// Cone returns the customer cone an AS declares in its aut-num exports,
+// including the AS itself, or nil when it declares none.
+func (db *Database) Cone(asn uint32) *Expansion {
+	if db == nil {
+		return nil
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if cone, ok := db.cones[asn]; ok {
+		return cone
+	}
+
+	var cone *Expansion
+	if terms := db.announce[asn]; len(terms) > 0 {
+		self := fmt.Sprintf("AS%d", asn)
+		cone = db.expandTerms(strings.Join(terms, " "), append([]string{self}, terms...), db.MaxDepth)
+	}
+	db.cones[asn] = cone
+	return cone
+}
+
+// ConeViolation is a hop that passed on routes from ASNs outside its
+// declared customer cone.
+type ConeViolation struct {
+	ASN     uint32
+	Cone    string   // Termos exportados pelo aut-num
+	Outside []uint32 // ASNs abaixo do hop que não estão no cone
+}
+
+// CheckCones checks, for every transit hop of the peer's path, that the
+// ASNs below it are in its declared customer cone. The peer's neighbour is
+// skipped: it may be sending a full table to the RING node.
+func (db *Database) CheckCones(peer *parser.Peer) []ConeViolation {
+	if db == nil {
+		return nil
+	}
+	path, _ := analysis.CollapsedPath(peer)
+
+	var violations []ConeViolation
+	for i := 1; i < len(path)-1; i++ {
+		cone := db.Cone(uint32(path[i]))
+		if cone == nil {
+			continue
+		}
+		violation := ConeViolation{ASN: uint32(path[i]), Cone: cone.Name}
+		for _, asn := range path[i+1:] {
+			if !cone.Contains(uint32(asn)) {
+				violation.Outside = append(violation.Outside, uint32(asn))
+			}
+		}
+		if len(violation.Outside) > 0 {
+			violations = append(violations, violation)
+		}
+	}
+	return violations
+}
@@ -0,0 +1,194 @@
+package irr
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/drksbr/lg2/pkg/parser"
+	"github.com/drksbr/lg2/pkg/rpki"
+)
+
+// RouteObject is a route: or route6: object.
+type RouteObject struct {
+	Prefix netip.Prefix
+	Origin uint32
+	Source string // Banco IRR (RADB, RIPE, ARIN...)
+}
+
+// Database holds the objects loaded from RPSL dumps.
+type Database struct {
+	routes   map[netip.Prefix][]RouteObject
+	count    int
+	asSets   map[string]ASSet
+	announce map[uint32][]string // Termos "announce" do export de cada aut-num
+
+	// Limite de aninhamento ao expandir cones de clientes
+	MaxDepth int
+
+	mu    sync.Mutex
+	cones map[uint32]*Expansion // Cones de clientes já expandidos
+}
+
+// NewDatabase returns an empty database.
+func NewDatabase() *Database {
+	return &Database{
+		routes:   map[netip.Prefix][]RouteObject{},
+		asSets:   map[string]ASSet{},
+		announce: map[uint32][]string{},
+		cones:    map[uint32]*Expansion{},
+	}
+}
+
+// LoadDumps reads every dump into a new database.
+func LoadDumps(paths []string) (*Database, error) {
+	db := NewDatabase()
+	for _, path := range paths {
+		if err := db.LoadDump(path); err != nil {
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// LoadDump adds the objects of one RPSL dump (plain or gzip). Objects
+// without a "source:" attribute take the source from the file name.
+func (db *Database) LoadDump(path string) error {
+	f, err := openDump(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fallback := sourceFromPath(path)
+	err = ReadObjects(f, func(obj *Object) error {
+		source := strings.ToUpper(obj.First("source"))
+		if source == "" {
+			source = fallback
+		}
+		db.add(obj, source)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	return nil
+}
+
+// add indexes the classes used by lg; others are ignored.
+func (db *Database) add(obj *Object, source string) {
+	switch obj.Class {
+	case "route", "route6":
+		prefix, err := netip.ParsePrefix(obj.Key)
+		if err != nil {
+			return
+		}
+		origin, err := rpki.ParseASN(obj.First("origin"))
+		if err != nil {
+			return
+		}
+		prefix = prefix.Masked()
+		for _, existing := range db.routes[prefix] {
+			if existing.Origin == origin && existing.Source == source {
+				return
+			}
+		}
+		db.routes[prefix] = append(db.routes[prefix], RouteObject{Prefix: prefix, Origin: origin, Source: source})
+		db.count++
+	case "as-set":
+		db.addASSet(obj, source)
+	case "aut-num":
+		db.addAutNum(obj)
+	}
+}
+
+// Routes returns the number of route objects loaded.
+func (db *Database) Routes() int {
+	if db == nil {
+		return 0
+	}
+	return db.count
+}
+
+// RouteObjects returns the route objects registered for exactly prefix.
+func (db *Database) RouteObjects(prefix netip.Prefix) []RouteObject {
+	if db == nil {
+		return nil
+	}
+	return db.routes[prefix.Masked()]
+}
+
+// RouteState is the IRR validation state of an announcement.
+type RouteState int
+
+const (
+	RouteUnknown  RouteState = iota // Sem dumps carregados
+	RouteValid                      // Existe route object com a mesma origem
+	RouteInvalid                    // Existem route objects, mas de outras origens
+	RouteNotFound                   // Nenhum route object para o prefixo
+)
+
+// String returns the lower-case name of the state.
+func (s RouteState) String() string {
+	switch s {
+	case RouteValid:
+		return "valid"
+	case RouteInvalid:
+		return "invalid"
+	case RouteNotFound:
+		return "not-found"
+	default:
+		return "unknown"
+	}
+}
+
+// RouteValidation is the IRR check of one announcement.
+type RouteValidation struct {
+	State    RouteState
+	Sources  []string      // Bancos com route object para a origem
+	Objects  []RouteObject // Todos os route objects do prefixo
+	Covering []RouteObject // Route objects menos específicos da mesma origem
+}
+
+// ValidateRoute checks whether a route object exists for the exact prefix
+// and origin, and in which sources.
+func (db *Database) ValidateRoute(prefix netip.Prefix, origin uint32, hasOrigin bool) RouteValidation {
+	if db == nil || !prefix.IsValid() {
+		return RouteValidation{State: RouteUnknown}
+	}
+
+	result := RouteValidation{Objects: db.RouteObjects(prefix)}
+	for _, obj := range result.Objects {
+		if hasOrigin && obj.Origin == origin {
+			result.Sources = append(result.Sources, obj.Source)
+		}
+	}
+	sort.Strings(result.Sources)
+
+	// Less specific objects help explain a missing exact match
+	for bits := prefix.Bits() - 1; bits >= 0 && hasOrigin; bits-- {
+		for _, obj := range db.routes[netip.PrefixFrom(prefix.Addr(), bits).Masked()] {
+			if obj.Origin == origin {
+				result.Covering = append(result.Covering, obj)
+			}
+		}
+	}
+
+	switch {
+	case len(result.Sources) > 0:
+		result.State = RouteValid
+	case len(result.Objects) > 0:
+		result.State = RouteInvalid
+	default:
+		result.State = RouteNotFound
+	}
+	return result
+}
+
+// ValidatePeer checks the route seen by a peer.
+func (db *Database) ValidatePeer(peer *parser.Peer) RouteValidation {
+	origin, ok := peer.OriginAS()
+	return db.ValidateRoute(peer.Prefix, uint32(origin), ok)
+}
@@ -0,0 +1,164 @@
+// Package irr reads local RPSL dumps (RADB, RIPE, ARIN split files) and
+// answers route object and AS-SET questions without network access.
+package irr
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Object is an RPSL object: its class, primary key and attributes in order.
+type Object struct {
+	Class      string
+	Key        string
+	Attributes []Attribute
+}
+
+// Attribute is an RPSL attribute with continuation lines joined.
+type Attribute struct {
+	Name  string
+	Value string
+}
+
+// Get returns every value of the named attribute.
+func (o *Object) Get(name string) []string {
+	var values []string
+	for _, attr := range o.Attributes {
+		if attr.Name == name {
+			values = append(values, attr.Value)
+		}
+	}
+	return values
+}
+
+// First returns the first value of the named attribute.
+func (o *Object) First(name string) string {
+	for _, attr := range o.Attributes {
+		if attr.Name == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// ReadObjects calls fn for every object in an RPSL stream. Objects are
+// separated by blank lines; "%" and "#" lines are comments and lines
+// starting with a space, tab or "+" continue the previous attribute.
+// Trailing "#" comments are removed from values.
+func ReadObjects(r io.Reader, fn func(*Object) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var current *Object
+	flush := func() error {
+		if current == nil || len(current.Attributes) == 0 {
+			current = nil
+			return nil
+		}
+		current.Class = current.Attributes[0].Name
+		current.Key = current.Attributes[0].Value
+		err := fn(current)
+		current = nil
+		return err
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			if err := flush(); err != nil {
+				return err
+			}
+			continue
+		}
+		if line[0] == '%' || line[0] == '#' {
+			continue
+		}
+
+		// Continuation of the previous attribute
+		if line[0] == ' ' || line[0] == '\t' || line[0] == '+' {
+			if current != nil && len(current.Attributes) > 0 {
+				last := &current.Attributes[len(current.Attributes)-1]
+				last.Value = strings.TrimSpace(last.Value + " " + stripComment(line[1:]))
+			}
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		if current == nil {
+			current = &Object{}
+		}
+		current.Attributes = append(current.Attributes, Attribute{
+			Name:  strings.ToLower(strings.TrimSpace(name)),
+			Value: stripComment(value),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
+
+func stripComment(value string) string {
+	if i := strings.Index(value, "#"); i >= 0 {
+		value = value[:i]
+	}
+	return strings.TrimSpace(value)
+}
+
+// openDump opens an RPSL dump, transparently decompressing gzip files.
+func openDump(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open RPSL dump: %v", err)
+	}
+
+	reader := bufio.NewReader(f)
+	magic, _ := reader.Peek(2)
+	if !bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		return struct {
+			io.Reader
+			io.Closer
+		}{reader, f}, nil
+	}
+
+	gz, err := gzip.NewReader(reader)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{gz, closers{gz, f}}, nil
+}
+
+type closers []io.Closer
+
+func (c closers) Close() error {
+	var first error
+	for _, closer := range c {
+		if err := closer.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// sourceFromPath guesses the IRR source from a dump file name, e.g.
+// "ripe.db.route.gz" -> "RIPE", "radb.db" -> "RADB".
+func sourceFromPath(path string) string {
+	name := filepath.Base(path)
+	if i := strings.IndexAny(name, "."); i > 0 {
+		name = name[:i]
+	}
+	return strings.ToUpper(name)
+}
@@ -0,0 +1,243 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// SegmentType identifies the kind of an AS_PATH segment (RFC 4271 / RFC 5065).
+type SegmentType int
+
+const (
+	SegmentSequence       SegmentType = iota // AS_SEQUENCE
+	SegmentSet                               // AS_SET
+	SegmentConfedSequence                    // AS_CONFED_SEQUENCE
+	SegmentConfedSet                         // AS_CONFED_SET
+)
+
+// String returns the RFC name of the segment type.
+func (t SegmentType) String() string {
+	switch t {
+	case SegmentSet:
+		return "AS_SET"
+	case SegmentConfedSequence:
+		return "AS_CONFED_SEQUENCE"
+	case SegmentConfedSet:
+		return "AS_CONFED_SET"
+	default:
+		return "AS_SEQUENCE"
+	}
+}
+
+// IsConfed reports whether the segment belongs to a confederation.
+func (t SegmentType) IsConfed() bool {
+	return t == SegmentConfedSequence || t == SegmentConfedSet
+}
+
+// IsSet reports whether the segment is unordered (AS_SET or AS_CONFED_SET).
+func (t SegmentType) IsSet() bool {
+	return t == SegmentSet || t == SegmentConfedSet
+}
+
+// PathSegment is a typed group of ASNs inside an AS_PATH.
+type PathSegment struct {
+	Type SegmentType // Tipo do segmento
+	ASNs []AsPath    // ASNs do segmento
+}
+
+// Length returns how much the segment adds to the path length per RFC 4271
+// section 9.1.2.2: each AS in a sequence counts, a set counts as one and
+// confederation segments do not count.
+func (s PathSegment) Length() int {
+	switch {
+	case s.Type.IsConfed() || len(s.ASNs) == 0:
+		return 0
+	case s.Type == SegmentSet:
+		return 1
+	default:
+		return len(s.ASNs)
+	}
+}
+
+// String renders the segment in BIRD notation: sets as {a b}, confederation
+// sequences as (a b) and confederation sets as [a b].
+func (s PathSegment) String() string {
+	asns := make([]string, len(s.ASNs))
+	for i, as := range s.ASNs {
+		asns[i] = strconv.Itoa(as.AsNumber)
+	}
+	joined := strings.Join(asns, " ")
+
+	switch s.Type {
+	case SegmentSet:
+		return "{" + joined + "}"
+	case SegmentConfedSequence:
+		return "(" + joined + ")"
+	case SegmentConfedSet:
+		return "[" + joined + "]"
+	default:
+		return joined
+	}
+}
+
+// PathLength returns the AS_PATH length used in best path selection.
+func PathLength(segments []PathSegment) int {
+	length := 0
+	for _, segment := range segments {
+		length += segment.Length()
+	}
+	return length
+}
+
+// OriginAS returns the origin AS of the path as defined in RFC 6811: the
+// rightmost AS of the final segment when it is an AS_SEQUENCE. Paths ending
+// in an AS_SET or a confederation segment, and empty paths, have no origin.
+func OriginAS(segments []PathSegment) (int, bool) {
+	if len(segments) == 0 {
+		return 0, false
+	}
+	last := segments[len(segments)-1]
+	if last.Type != SegmentSequence || len(last.ASNs) == 0 {
+		return 0, false
+	}
+	return last.ASNs[len(last.ASNs)-1].AsNumber, true
+}
+
+// FlattenSegments returns every ASN of the segments in path order.
+func FlattenSegments(segments []PathSegment) []AsPath {
+	var flat []AsPath
+	for _, segment := range segments {
+		flat = append(flat, segment.ASNs...)
+	}
+	return flat
+}
+
+// PathSegments returns the typed segments of the peer's AS_PATH. Peers built
+// without segment information are treated as a single AS_SEQUENCE.
+func (p *Peer) PathSegments() []PathSegment {
+	if len(p.Segments) > 0 || len(p.AsPath) == 0 {
+		return p.Segments
+	}
+	return []PathSegment{{Type: SegmentSequence, ASNs: p.AsPath}}
+}
+
+// PathLength returns the RFC 4271 length of the peer's AS_PATH.
+func (p *Peer) PathLength() int {
+	return PathLength(p.PathSegments())
+}
+
+// OriginAS returns the origin AS of the peer's route, if it has one.
+func (p *Peer) OriginAS() (int, bool) {
+	return OriginAS(p.PathSegments())
+}
+
+// parseASPathCell walks the AS-Path cell in document order, collecting the
+// ASN buttons and the bracket characters that delimit sets and
+// confederation segments.
+func parseASPathCell(cell *goquery.Selection) []PathSegment {
+	builder := &segmentBuilder{}
+	walkASPathNodes(cell, builder)
+	segments, _ := builder.finish()
+	return segments
+}
+
+func walkASPathNodes(sel *goquery.Selection, builder *segmentBuilder) {
+	sel.Contents().Each(func(_ int, node *goquery.Selection) {
+		switch goquery.NodeName(node) {
+		case "#text":
+			for _, r := range node.Text() {
+				if strings.ContainsRune("{}()[]", r) {
+					// Malformed brackets are ignored; the ASNs are still kept
+					_ = builder.delimiter(r)
+				}
+			}
+		case "button":
+			builder.add(asPathFromButton(node))
+		default:
+			walkASPathNodes(node, builder)
+		}
+	})
+}
+
+// asPathFromButton extracts the ASN, name and country of an AS-Path button.
+func asPathFromButton(btn *goquery.Selection) AsPath {
+	number, _ := strconv.Atoi(btn.Find("a.whois.asn").Text())
+	name := btn.AttrOr("title", "")
+
+	// Extract Country from the name if available
+	var country string
+	if parts := strings.Split(name, ","); len(parts) > 1 {
+		if code := strings.TrimSpace(parts[len(parts)-1]); len(code) >= 2 {
+			country = strings.ToUpper(code[:2])
+		}
+		name = strings.TrimSpace(parts[0])
+	}
+
+	// Discard anything after <br> in the name
+	if brIndex := strings.Index(name, "<br>"); brIndex != -1 {
+		name = strings.TrimSpace(name[:brIndex])
+	}
+
+	return AsPath{AsNumber: number, AsName: name, Country: country}
+}
+
+// segmentBuilder groups ASNs into typed segments as delimiters are seen.
+type segmentBuilder struct {
+	segments []PathSegment
+	current  *PathSegment
+	closer   rune // Delimitador que fecha o segmento aberto, 0 se nenhum
+}
+
+func (b *segmentBuilder) add(as AsPath) {
+	if b.current == nil {
+		b.current = &PathSegment{Type: SegmentSequence}
+	}
+	b.current.ASNs = append(b.current.ASNs, as)
+}
+
+func (b *segmentBuilder) delimiter(r rune) error {
+	switch r {
+	case '{', '(', '[':
+		if b.closer != 0 {
+			return fmt.Errorf("nested %q in AS path", r)
+		}
+		b.close()
+		b.closer = map[rune]rune{'{': '}', '(': ')', '[': ']'}[r]
+		b.current = &PathSegment{Type: map[rune]SegmentType{
+			'{': SegmentSet,
+			'(': SegmentConfedSequence,
+			'[': SegmentConfedSet,
+		}[r]}
+	default:
+		if b.closer == 0 {
+			return fmt.Errorf("unbalanced %q in AS path", r)
+		}
+		if r != b.closer {
+			// The segment stays open: its own delimiter may still follow
+			return fmt.Errorf("%q where %q was expected in AS path", r, b.closer)
+		}
+		b.close()
+	}
+	return nil
+}
+
+// close ends the current segment and keeps it if it has ASNs.
+func (b *segmentBuilder) close() {
+	if b.current != nil && len(b.current.ASNs) > 0 {
+		b.segments = append(b.segments, *b.current)
+	}
+	b.current = nil
+	b.closer = 0
+}
+
+func (b *segmentBuilder) finish() ([]PathSegment, error) {
+	open := b.closer != 0
+	b.close()
+	if open {
+		return b.segments, fmt.Errorf("unterminated segment in AS path")
+	}
+	return b.segments, nil
+}
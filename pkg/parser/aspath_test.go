@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// segmentsOf renders the segments in BIRD notation, one per element.
+func segmentsOf(segments []PathSegment) string {
+	parts := make([]string, len(segments))
+	for i, segment := range segments {
+		parts[i] = segment.String()
+	}
+	return strings.Join(parts, " | ")
+}
+
+func TestSegmentBuilderMismatchedClose(t *testing.T) {
+	builder := &segmentBuilder{}
+	builder.add(AsPath{AsNumber: 64500})
+	if err := builder.delimiter('{'); err != nil {
+		t.Fatal(err)
+	}
+	builder.add(AsPath{AsNumber: 64501})
+	if err := builder.delimiter(')'); err == nil {
+		t.Error("')' closed a set opened with '{'")
+	}
+	builder.add(AsPath{AsNumber: 64502})
+	if err := builder.delimiter('}'); err != nil {
+		t.Fatal(err)
+	}
+	builder.add(AsPath{AsNumber: 64503})
+
+	segments, err := builder.finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := segmentsOf(segments), "64500 | {64501 64502} | 64503"; got != want {
+		t.Errorf("segments = %s, want %s", got, want)
+	}
+}
+
+func TestParseASPathCellBrackets(t *testing.T) {
+	button := func(asn string) string {
+		return `<button title="Example, NL"><a class="whois asn">` + asn + `</a></button>`
+	}
+	html := `<td>` + button("64500") + ` (` + button("64510") + button("64511") + `] ) ` + button("64501") + `</td>`
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader("<table><tr>" + html + "</tr></table>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	segments := parseASPathCell(doc.Find("td"))
+	if got, want := segmentsOf(segments), "64500 | (64510 64511) | 64501"; got != want {
+		t.Errorf("segments = %s, want %s", got, want)
+	}
+	if origin, ok := OriginAS(segments); !ok || origin != 64501 {
+		t.Errorf("OriginAS = %d, %v, want 64501", origin, ok)
+	}
+}
@@ -0,0 +1,106 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SourceLocation is the timezone assumed for "Last update" values that
+// carry no zone information of their own.
+var SourceLocation = time.UTC
+
+// Layouts accepted for "Last update", from the most to the least specific.
+var lastUpdateLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 -07:00",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// Layouts used by BIRD for routes learned today (time only).
+var lastUpdateClockLayouts = []string{
+	"15:04:05.999999999",
+	"15:04:05",
+}
+
+// ParseLastUpdate converts the free-text "Last update" value into a time.Time.
+// Values without an explicit zone are interpreted in loc; a nil loc means
+// SourceLocation. Time-only values are anchored to the current day in loc.
+func ParseLastUpdate(value string, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = SourceLocation
+	}
+
+	value = strings.Join(strings.Fields(value), " ")
+	if value == "" {
+		return time.Time{}, fmt.Errorf("empty last update")
+	}
+
+	for _, layout := range lastUpdateLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	for _, layout := range lastUpdateClockLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			now := time.Now().In(loc)
+			t = time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+			// A clock value ahead of now refers to yesterday
+			if t.After(now) {
+				t = t.AddDate(0, 0, -1)
+			}
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized last update format: %q", value)
+}
+
+// Age returns how long ago the route was last updated. The second value is
+// false when the "Last update" field could not be parsed.
+func (p *Peer) Age(now time.Time) (time.Duration, bool) {
+	if p.UpdatedAt.IsZero() {
+		return 0, false
+	}
+	age := now.Sub(p.UpdatedAt)
+	if age < 0 {
+		age = 0
+	}
+	return age, true
+}
+
+// RecentlyChanged reports whether the route was updated within window.
+func (p *Peer) RecentlyChanged(now time.Time, window time.Duration) bool {
+	age, ok := p.Age(now)
+	return ok && window > 0 && age <= window
+}
+
+// FormatAge renders a route age in the compact "3d4h" form.
+func FormatAge(age time.Duration) string {
+	age = age.Truncate(time.Second)
+	days := int(age / (24 * time.Hour))
+	hours := int(age % (24 * time.Hour) / time.Hour)
+	minutes := int(age % time.Hour / time.Minute)
+	seconds := int(age % time.Minute / time.Second)
+
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd%dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dm%ds", minutes, seconds)
+	default:
+		return fmt.Sprintf("%ds", seconds)
+	}
+}
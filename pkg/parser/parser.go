@@ -2,9 +2,9 @@ package parser
 
 import (
 	"fmt"
-	"net"
-	"strconv"
+	"net/netip"
 	"strings"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 )
@@ -16,20 +16,26 @@ type AsPath struct {
 }
 
 type Peer struct {
-	PeerName          string   // Nome do peer
-	AsPath            []AsPath // Caminho de ASNs
-	OriginValidation  string   // Estado de validação de origem
-	AspaValidation    string   // Estado de validação ASPA
-	OnlyToCustomerOTC string   // Informações de "Only To Customer"
-	Origin            string   // Origem
-	Med               string   // MED (Multi Exit Discriminator)
-	LastUpdate        string   // Última atualização
-	Communities       []string // Comunidades
-	Prefix            string   // Prefixo
+	PeerName          string        // Nome do peer
+	Info              PeerInfo      // Identidade estruturada do peer
+	AsPath            []AsPath      // Caminho de ASNs
+	Segments          []PathSegment // Segmentos tipados do caminho (AS_SEQUENCE, AS_SET, ...)
+	OriginValidation  string        // Estado de validação de origem
+	AspaValidation    string        // Estado de validação ASPA
+	OnlyToCustomerOTC string        // Informações de "Only To Customer"
+	Origin            string        // Origem
+	Med               string        // MED (Multi Exit Discriminator)
+	LastUpdate        string        // Última atualização
+	UpdatedAt         time.Time     // Última atualização convertida (zero se desconhecida)
+	Communities       []string      // Comunidades
+	Prefix            netip.Prefix  // Prefixo
 }
 
-// ParseHTML parses the updated HTML format and extracts peer information
-func ParseHTML(htmlData string, prefix string) ([]Peer, error) {
+// ParseHTML parses the updated HTML format and extracts peer information.
+// Each peer's Prefix is the route the looking glass returned for it; when
+// the page does not show one, queried is used for exact-match prefix
+// queries and the prefix stays invalid for address queries.
+func ParseHTML(htmlData string, queried netip.Prefix) ([]Peer, error) {
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse HTML: %v", err)
@@ -40,41 +46,28 @@ func ParseHTML(htmlData string, prefix string) ([]Peer, error) {
 	doc.Find("div.peername").Each(func(index int, peerNode *goquery.Selection) {
 		peer := Peer{}
 
-		// Set Prefix
-		peer.Prefix = prefix
-
 		// Extract Peer Name
-		peer.PeerName = strings.Split(strings.TrimSpace(peerNode.Find(".me-auto").Text()), " ")[1]
+		header := strings.TrimSpace(peerNode.Find(".me-auto").Text())
+		peer.PeerName = strings.Split(header, " ")[1]
+		peer.Info = ParsePeerInfo(header)
 
 		// Navigate to the corresponding table for the peer
 		peerTable := peerNode.NextFiltered("table")
 
+		// Route returned for this peer
+		peer.Prefix = routePrefix(peerNode, peerTable, header)
+		if !peer.Prefix.IsValid() {
+			peer.Prefix = queried
+		}
+
 		peerTable.Find("tr").Each(func(_ int, row *goquery.Selection) {
 			header := strings.TrimSpace(row.Find("td:first-child").Text())
 			data := row.Find("td")
 
 			switch header {
 			case "AS-Path":
-				var asPath []AsPath
-				data.Find("button").Each(func(_ int, btn *goquery.Selection) {
-					number, _ := strconv.Atoi(btn.Find("a.whois.asn").Text())
-					name := btn.AttrOr("title", "")
-
-					// Extract Country from the name if available
-					var country string
-					if parts := strings.Split(name, ","); len(parts) > 1 {
-						country = strings.ToUpper(strings.TrimSpace(parts[len(parts)-1])[:2])
-						name = strings.TrimSpace(parts[0])
-					}
-
-					// Discard anything after <br> in the name
-					if brIndex := strings.Index(name, "<br>"); brIndex != -1 {
-						name = strings.TrimSpace(name[:brIndex])
-					}
-
-					asPath = append(asPath, AsPath{AsNumber: number, AsName: name, Country: country})
-				})
-				peer.AsPath = asPath
+				peer.Segments = parseASPathCell(data)
+				peer.AsPath = FlattenSegments(peer.Segments)
 
 			case "Origin validation state":
 				peer.OriginValidation = data.Text()
@@ -93,6 +86,9 @@ func ParseHTML(htmlData string, prefix string) ([]Peer, error) {
 
 			case "Last update":
 				peer.LastUpdate = strings.TrimSpace(strings.TrimPrefix(data.Text(), "Last update"))
+				if updatedAt, err := ParseLastUpdate(peer.LastUpdate, nil); err == nil {
+					peer.UpdatedAt = updatedAt
+				}
 
 			case "Communities":
 				var communities []string
@@ -109,35 +105,36 @@ func ParseHTML(htmlData string, prefix string) ([]Peer, error) {
 	return peers, nil
 }
 
-func GetNetworkFromPrefix(prefix string) (*net.IPNet, error) {
-
-	// Check if prefix is a domain name and resolve it
-	if strings.Contains(prefix, ".") {
-		ips, err := net.LookupIP(prefix)
-		if err != nil {
-			return nil, fmt.Errorf("failed to resolve domain: %v", err)
-		}
-		if len(ips) == 0 {
-			return nil, fmt.Errorf("no IP addresses found for domain")
+// routePrefixRows are the table rows that may hold the returned route.
+var routePrefixRows = map[string]bool{"Prefix": true, "Network": true, "Route": true}
+
+// routePrefix finds the route prefix the looking glass shows for a peer: in
+// a prefix row of its table, in its header, or in the heading of the group
+// of peers it belongs to.
+func routePrefix(peerNode, peerTable *goquery.Selection, header string) netip.Prefix {
+	var prefix netip.Prefix
+	peerTable.Find("tr").EachWithBreak(func(_ int, row *goquery.Selection) bool {
+		if routePrefixRows[strings.TrimSpace(row.Find("td:first-child").Text())] {
+			prefix = findPrefix(row.Find("td").Last().Text())
 		}
-		prefix = ips[0].String()
+		return !prefix.IsValid()
+	})
+	if prefix.IsValid() {
+		return prefix
 	}
-
-	// Add default mask if not provided
-	if !strings.Contains(prefix, "/") {
-		ip := net.ParseIP(prefix)
-		if ip != nil {
-			if ip.To4() != nil {
-				prefix += "/24"
-			} else {
-				prefix += "/48"
-			}
-		}
+	if prefix = findPrefix(header); prefix.IsValid() {
+		return prefix
 	}
+	return findPrefix(peerNode.PrevAllFiltered("h1, h2, h3, h4, h5, h6").First().Text())
+}
 
-	_, network, err := net.ParseCIDR(prefix)
-	if err != nil {
-		return nil, fmt.Errorf("invalid prefix: %v", err)
+// findPrefix returns the first prefix in text, or an invalid prefix.
+func findPrefix(text string) netip.Prefix {
+	for _, field := range strings.Fields(text) {
+		field = strings.Trim(field, "()[],;:")
+		if prefix, err := netip.ParsePrefix(field); err == nil {
+			return prefix.Masked()
+		}
 	}
-	return network, nil
+	return netip.Prefix{}
 }
@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PeerInfo is the structured identity of a looking glass peer.
+type PeerInfo struct {
+	Node         string     // Nome do nó do ring (ex: coloclue01)
+	Organisation string     // Organisação que hospeda o nó (ex: coloclue)
+	ASN          int        // ASN do peer (0 se desconhecido)
+	Address      netip.Addr // Endereço do roteador
+	Country      string     // Sigla do país (se conhecida)
+	City         string     // Cidade (se conhecida)
+}
+
+// ringDomain is the suffix of NLNOG RING node hostnames.
+const ringDomain = ".ring.nlnog.net"
+
+var peerASNPattern = regexp.MustCompile(`(?i)\bAS(\d+)\b`)
+
+// ParsePeerInfo extracts the structured identity from the peer header text.
+// The node name follows the RING convention "<organisation><number>", e.g.
+// "coloclue01"; the ASN and router address are picked up wherever they
+// appear in the header.
+func ParsePeerInfo(header string) PeerInfo {
+	info := PeerInfo{}
+	fields := strings.Fields(header)
+
+	if len(fields) > 1 {
+		info.Node = NodeName(fields[1])
+	}
+	info.Organisation = NodeOrganisation(info.Node)
+
+	if match := peerASNPattern.FindStringSubmatch(header); match != nil {
+		info.ASN, _ = strconv.Atoi(match[1])
+	}
+
+	for _, field := range fields {
+		field = strings.Trim(field, "()[],;")
+		if addr, err := netip.ParseAddr(field); err == nil {
+			info.Address = addr.Unmap()
+			break
+		}
+	}
+
+	return info
+}
+
+// NodeName normalises a RING node name by lowercasing it and removing the
+// ring domain.
+func NodeName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ringDomain)
+}
+
+// NodeOrganisation derives the hosting organisation from a RING node name by
+// dropping the trailing node number ("coloclue01" -> "coloclue").
+func NodeOrganisation(node string) string {
+	return strings.TrimRight(node, "0123456789")
+}
+
+// Location returns "City, CC" with whichever parts are known.
+func (i PeerInfo) Location() string {
+	switch {
+	case i.City != "" && i.Country != "":
+		return i.City + ", " + i.Country
+	case i.City != "":
+		return i.City
+	default:
+		return i.Country
+	}
+}
@@ -0,0 +1,206 @@
+package parser
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// Query is a looking glass query resolved from the user input.
+type Query struct {
+	Input    string       // Texto digitado pelo usuário
+	Host     string       // Hostname resolvido (vazio se o input era um endereço)
+	Addr     netip.Addr   // Endereço informado sem máscara (inválido para prefixos)
+	Prefix   netip.Prefix // Prefixo consultado (inválido para endereços)
+	Warnings []string     // Ajustes feitos no input
+}
+
+// LookingGlassQuery returns the string sent to the looking glass: the bare
+// address when no prefix length was given, the prefix otherwise.
+func (q Query) LookingGlassQuery() string {
+	if q.Addr.IsValid() {
+		return q.Addr.String()
+	}
+	return q.Prefix.String()
+}
+
+// queryHelp is appended to input errors to show what is accepted.
+const queryHelp = "expected an IP address, a prefix (1.1.1.0/24, 2001:db8::/32), a hostname or a URL"
+
+// ResolveQueries turns the user input into queries. Besides addresses and
+// prefixes it accepts URLs, bracketed IPv6 addresses and host:port forms.
+// Hostnames are resolved through DefaultResolver and yield one query per A
+// and AAAA record, IPv4 first.
+func ResolveQueries(input string) ([]Query, error) {
+	host := ExtractHost(input)
+	if host == "" {
+		return nil, fmt.Errorf("empty query: %s", queryHelp)
+	}
+
+	// Address or prefix given directly
+	if query, err := ParseQuery(host); err == nil {
+		query.Input = input
+		return []Query{query}, nil
+	} else if strings.Contains(host, "/") || !isHostname(host) {
+		return nil, err
+	}
+
+	// Hostname: resolve it
+	addrs, err := lookupHost(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve domain %q: %v", host, err)
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no IP addresses found for domain %q", host)
+	}
+
+	queries := make([]Query, 0, len(addrs))
+	for _, addr := range addrs {
+		query := addressQuery(addr)
+		query.Input = input
+		query.Host = host
+		queries = append(queries, query)
+	}
+	return queries, nil
+}
+
+// String returns the queried prefix, or the address for address queries.
+func (q Query) String() string {
+	return q.LookingGlassQuery()
+}
+
+// Is4 reports whether the query is for IPv4.
+func (q Query) Is4() bool {
+	if q.Addr.IsValid() {
+		return q.Addr.Is4()
+	}
+	return q.Prefix.Addr().Is4()
+}
+
+// Family returns "IPv4" or "IPv6" for the query.
+func (q Query) Family() string {
+	if q.Is4() {
+		return "IPv4"
+	}
+	return "IPv6"
+}
+
+// RoutePrefix returns the prefix the checks of a query apply to: the
+// queried prefix, or for address queries the route most peers returned.
+func (q Query) RoutePrefix(peers []Peer) netip.Prefix {
+	if q.Prefix.IsValid() {
+		return q.Prefix
+	}
+	counts := map[netip.Prefix]int{}
+	var best netip.Prefix
+	for i := range peers {
+		prefix := peers[i].Prefix
+		if !prefix.IsValid() {
+			continue
+		}
+		counts[prefix]++
+		if n := counts[prefix]; n > counts[best] || (n == counts[best] && prefix.Bits() > best.Bits()) {
+			best = prefix
+		}
+	}
+	return best
+}
+
+// ParseQuery parses an address or prefix without touching the network.
+// Host bits set in a prefix are cleared and reported as a warning.
+func ParseQuery(text string) (Query, error) {
+	if strings.Contains(text, "/") {
+		prefix, err := netip.ParsePrefix(text)
+		if err != nil {
+			return Query{}, fmt.Errorf("invalid prefix %q: %s", text, queryHelp)
+		}
+		query := Query{Input: text}
+		if prefix.Addr().Is4In6() {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+			if !prefix.IsValid() {
+				return Query{}, fmt.Errorf("invalid prefix %q: IPv4-mapped prefixes need a length of at least /96", text)
+			}
+			query.Warnings = append(query.Warnings, fmt.Sprintf("IPv4-mapped prefix %s queried as %s", text, prefix))
+		}
+		if masked := prefix.Masked(); masked != prefix {
+			query.Warnings = append(query.Warnings, fmt.Sprintf("host bits set in %s, using %s", prefix, masked))
+			prefix = masked
+		}
+		query.Prefix = prefix
+		return query, nil
+	}
+
+	addr, err := netip.ParseAddr(text)
+	if err != nil {
+		return Query{}, fmt.Errorf("invalid query %q: %s", text, queryHelp)
+	}
+	query := addressQuery(addr.WithZone("").Unmap())
+	query.Input = text
+	if addr.Zone() != "" {
+		query.Warnings = append(query.Warnings, fmt.Sprintf("zone %q ignored", addr.Zone()))
+	}
+	return query, nil
+}
+
+// addressQuery builds the query for a bare address. The looking glass is
+// asked for the address itself and the prefix is left unset: the routes it
+// returns tell which prefix covers the address.
+func addressQuery(addr netip.Addr) Query {
+	return Query{Addr: addr}
+}
+
+// ExtractHost pulls the address or hostname out of URLs ("https://host/x"),
+// bracketed IPv6 ("[2001:db8::1]:443") and host:port forms.
+func ExtractHost(input string) string {
+	text := strings.TrimSpace(input)
+
+	if strings.Contains(text, "://") {
+		if u, err := url.Parse(text); err == nil && u.Host != "" {
+			return strings.TrimSuffix(u.Hostname(), ".")
+		}
+	}
+
+	if strings.HasPrefix(text, "[") {
+		if host, _, err := net.SplitHostPort(text); err == nil {
+			return host
+		}
+		if end := strings.Index(text, "]"); end > 0 {
+			// "[2001:db8::]/32" keeps its length
+			return text[1:end] + text[end+1:]
+		}
+	}
+
+	// host:port (a single colon never appears in an IPv6 address)
+	if strings.Count(text, ":") == 1 {
+		if host, _, err := net.SplitHostPort(text); err == nil {
+			return host
+		}
+	}
+
+	return strings.TrimSuffix(text, ".")
+}
+
+// isHostname reports whether text is a syntactically valid DNS name.
+func isHostname(text string) bool {
+	if len(text) == 0 || len(text) > 253 {
+		return false
+	}
+	labels := strings.Split(text, ".")
+	// A numeric last label is a mistyped address, not a name
+	if strings.Trim(labels[len(labels)-1], "0123456789") == "" {
+		return false
+	}
+	for _, label := range labels {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
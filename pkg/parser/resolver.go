@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"time"
+)
+
+// Resolver looks up the addresses of a hostname. *net.Resolver satisfies it.
+type Resolver interface {
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+// DefaultResolver is used to resolve hostnames typed as queries.
+var DefaultResolver Resolver = net.DefaultResolver
+
+// NewDNSResolver returns a resolver that sends every lookup to the given DNS
+// server ("9.9.9.9", "9.9.9.9:53" or "[2620:fe::fe]:53").
+func NewDNSResolver(server string) Resolver {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(strings.Trim(server, "[]"), "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			dialer := net.Dialer{Timeout: resolveTimeout}
+			return dialer.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// StaticResolver answers lookups from a fixed table, for offline use.
+type StaticResolver map[string][]netip.Addr
+
+// LookupNetIP implements Resolver. network is "ip", "ip4" or "ip6".
+func (r StaticResolver) LookupNetIP(_ context.Context, network, host string) ([]netip.Addr, error) {
+	var addrs []netip.Addr
+	for _, addr := range r[strings.ToLower(strings.TrimSuffix(host, "."))] {
+		if network == "ip4" && !addr.Is4() || network == "ip6" && !addr.Is6() {
+			continue
+		}
+		addrs = append(addrs, addr)
+	}
+	if len(addrs) == 0 {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	return addrs, nil
+}
+
+// LoadHostsFile reads a hosts(5) style file ("address name [name...]") into a
+// StaticResolver.
+func LoadHostsFile(path string) (StaticResolver, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open hosts file: %v", err)
+	}
+	defer f.Close()
+
+	resolver := StaticResolver{}
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		addr, err := netip.ParseAddr(fields[0])
+		if err != nil || len(fields) < 2 {
+			return nil, fmt.Errorf("%s:%d: expected \"address name\"", path, line)
+		}
+		for _, name := range fields[1:] {
+			name = strings.ToLower(strings.TrimSuffix(name, "."))
+			resolver[name] = append(resolver[name], addr.Unmap())
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read hosts file: %v", err)
+	}
+	return resolver, nil
+}
+
+// lookupHost resolves a hostname through DefaultResolver, returning the
+// IPv4 addresses before the IPv6 ones.
+func lookupHost(host string) ([]netip.Addr, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+
+	addrs, err := DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[netip.Addr]bool{}
+	var v4, v6 []netip.Addr
+	for _, addr := range addrs {
+		addr = addr.Unmap()
+		if seen[addr] {
+			continue
+		}
+		seen[addr] = true
+		if addr.Is4() {
+			v4 = append(v4, addr)
+		} else {
+			v6 = append(v6, addr)
+		}
+	}
+	return append(v4, v6...), nil
+}
+
+// resolveTimeout bounds hostname resolution for a query.
+const resolveTimeout = 5 * time.Second
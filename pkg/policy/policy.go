@@ -0,0 +1,239 @@
+// Package policy loads per-prefix routing declarations (expected origins,
+// upstreams and communities) and checks the routes seen by the peers
+// against them.
+package policy
+
+import (
+	"bufio"
+	"fmt"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/drksbr/lg2/pkg/analysis"
+	"github.com/drksbr/lg2/pkg/community"
+	"github.com/drksbr/lg2/pkg/parser"
+	"github.com/drksbr/lg2/pkg/rpki"
+)
+
+// Rule holds the declarations for one prefix and its more-specifics.
+type Rule struct {
+	Prefix      netip.Prefix
+	Origins     []uint32 // Origens esperadas
+	Upstreams   []uint32 // Upstreams permitidos (vizinhos da origem)
+	Communities []string // Comunidades exigidas em toda rota
+}
+
+// ExpectsOrigin reports whether the rule allows the origin. A rule without
+// origins allows any.
+func (r *Rule) ExpectsOrigin(origin uint32) bool {
+	if len(r.Origins) == 0 {
+		return true
+	}
+	for _, expected := range r.Origins {
+		if expected == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// ExpectsUpstream reports whether the rule allows the upstream. A rule
+// without upstreams allows any.
+func (r *Rule) ExpectsUpstream(upstream uint32) bool {
+	return len(r.Upstreams) == 0 || slices.Contains(r.Upstreams, upstream)
+}
+
+// Violation kinds.
+const (
+	ViolationUpstream    = "unexpected-upstream"
+	ViolationCommunity   = "missing-community"
+	ViolationUnevaluated = "upstream-not-evaluated" // Caminho sem origem (AS_SET)
+)
+
+// Violation is a route that does not follow the declarations of its rule,
+// or whose upstream could not be checked against them.
+type Violation struct {
+	Kind    string // unexpected-upstream, missing-community ou upstream-not-evaluated
+	Message string
+}
+
+// Unevaluated reports whether the route could not be checked, rather than
+// breaking the rule.
+func (v Violation) Unevaluated() bool {
+	return v.Kind == ViolationUnevaluated
+}
+
+// CheckPeer checks the peer's route against the declared upstreams and
+// communities. The upstream is the AS adjacent to the origin, ignoring ASes
+// inserted after it by poisoning; when the path holds only the origin, the
+// peer's own AS is its upstream. A path ending in an AS_SET has no origin
+// to check and is reported as not evaluated.
+func (r *Rule) CheckPeer(peer *parser.Peer) []Violation {
+	var violations []Violation
+	if len(r.Upstreams) > 0 {
+		upstream, ok := analysis.Upstream(peer)
+		hops, endsInSet := analysis.OriginHops(peer)
+		if !ok && len(hops) == 1 && !endsInSet && peer.Info.ASN != 0 {
+			upstream, ok = peer.Info.ASN, true
+		}
+		if endsInSet {
+			violations = append(violations, Violation{
+				Kind:    ViolationUnevaluated,
+				Message: fmt.Sprintf("path ends in an AS_SET: cannot evaluate the upstream against %s", formatASNs(r.Upstreams)),
+			})
+		}
+		if ok && !r.ExpectsUpstream(uint32(upstream)) {
+			violations = append(violations, Violation{
+				Kind:    ViolationUpstream,
+				Message: fmt.Sprintf("reaches the origin via AS%d, %s is only expected via %s", upstream, r.Prefix, formatASNs(r.Upstreams)),
+			})
+		}
+	}
+
+	for _, required := range r.Communities {
+		entry := community.Entry{Pattern: required}
+		if !slices.ContainsFunc(peer.Communities, func(text string) bool { return entry.Matches(community.Normalize(text)) }) {
+			violations = append(violations, Violation{
+				Kind:    ViolationCommunity,
+				Message: fmt.Sprintf("route is not tagged %s, required for %s", required, r.Prefix),
+			})
+		}
+	}
+	return violations
+}
+
+// Policy is a set of rules.
+type Policy struct {
+	Rules []Rule
+}
+
+// LoadFile reads a policy file. Each line holds a prefix followed by
+// key=value declarations with comma-separated values; "#" starts a comment:
+//
+//	192.0.2.0/24   origin=AS64500,AS64501
+//	198.51.100.0/24 origin=AS64500 upstream=AS3356,AS174 community=65000:100
+//
+// Any listed origin or upstream is accepted; every listed community is
+// required, with "*" matching any value of a field (65000:*).
+func LoadFile(path string) (*Policy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open policy file: %v", err)
+	}
+	defer f.Close()
+
+	policy := &Policy{}
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s: line %d: invalid prefix %q", path, line, fields[0])
+		}
+		rule := Rule{Prefix: prefix.Masked()}
+		for _, field := range fields[1:] {
+			if err := rule.parse(field); err != nil {
+				return nil, fmt.Errorf("%s: line %d: %v", path, line, err)
+			}
+		}
+		policy.Rules = append(policy.Rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return policy, nil
+}
+
+// parse applies one key=value declaration to the rule.
+func (r *Rule) parse(field string) error {
+	key, value, ok := strings.Cut(field, "=")
+	if !ok || value == "" {
+		return fmt.Errorf("expected key=value, got %q", field)
+	}
+	values := strings.Split(value, ",")
+
+	switch strings.ToLower(key) {
+	case "origin":
+		for _, text := range values {
+			asn, err := rpki.ParseASN(text)
+			if err != nil {
+				return err
+			}
+			r.Origins = append(r.Origins, asn)
+		}
+	case "upstream":
+		for _, text := range values {
+			asn, err := rpki.ParseASN(text)
+			if err != nil {
+				return err
+			}
+			r.Upstreams = append(r.Upstreams, asn)
+		}
+	case "community":
+		for _, text := range values {
+			text = community.Normalize(text)
+			if n := strings.Count(text, ":"); n < 1 || n > 2 {
+				return fmt.Errorf("invalid community %q", text)
+			}
+			r.Communities = append(r.Communities, text)
+		}
+	default:
+		return fmt.Errorf("unknown declaration %q", key)
+	}
+	return nil
+}
+
+// Lookup returns the most specific rule covering prefix.
+func (p *Policy) Lookup(prefix netip.Prefix) (*Rule, bool) {
+	if p == nil || !prefix.IsValid() {
+		return nil, false
+	}
+	var best *Rule
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if rule.Prefix.Bits() <= prefix.Bits() && rule.Prefix.Contains(prefix.Addr()) {
+			if best == nil || rule.Prefix.Bits() > best.Prefix.Bits() {
+				best = rule
+			}
+		}
+	}
+	return best, best != nil
+}
+
+// CheckPeer checks the peer's route against the most specific rule
+// covering prefix.
+func (p *Policy) CheckPeer(prefix netip.Prefix, peer *parser.Peer) []Violation {
+	rule, ok := p.Lookup(prefix)
+	if !ok {
+		return nil
+	}
+	return rule.CheckPeer(peer)
+}
+
+// UnexpectedOrigin reports whether the prefix has declared origins and the
+// given one is not among them.
+func (p *Policy) UnexpectedOrigin(prefix netip.Prefix, origin int, hasOrigin bool) bool {
+	rule, ok := p.Lookup(prefix)
+	if !ok || len(rule.Origins) == 0 {
+		return false
+	}
+	return !hasOrigin || !rule.ExpectsOrigin(uint32(origin))
+}
+
+// formatASNs renders ASNs as "AS1 or AS2".
+func formatASNs(asns []uint32) string {
+	parts := make([]string, len(asns))
+	for i, asn := range asns {
+		parts[i] = fmt.Sprintf("AS%d", asn)
+	}
+	return strings.Join(parts, " or ")
+}
@@ -0,0 +1,449 @@
+// Package report runs the route checks shared by the TUI and the
+// non-interactive output, and renders query results as JSON or as alerts.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/drksbr/lg2/pkg/analysis"
+	"github.com/drksbr/lg2/pkg/asrel"
+	"github.com/drksbr/lg2/pkg/bogon"
+	"github.com/drksbr/lg2/pkg/community"
+	"github.com/drksbr/lg2/pkg/config"
+	"github.com/drksbr/lg2/pkg/ip2asn"
+	"github.com/drksbr/lg2/pkg/irr"
+	"github.com/drksbr/lg2/pkg/parser"
+	"github.com/drksbr/lg2/pkg/policy"
+	"github.com/drksbr/lg2/pkg/ring"
+	"github.com/drksbr/lg2/pkg/rpki"
+)
+
+// Options carries the datasets loaded by the CLI. Every field is optional.
+type Options struct {
+	Nodes         *ring.NodeList        // Lista local de nós do RING
+	VRPs          rpki.Validator        // VRPs para validação de origem local
+	ASPAs         *rpki.ASPASet         // Objetos ASPA para verificação de caminho
+	IRR           *irr.Database         // Route objects dos dumps RPSL
+	Relationships *asrel.Set            // Relações entre ASes do CAIDA
+	Bogons        *bogon.List           // ASNs e prefixos que não devem ser roteados
+	Policy        *policy.Policy        // Declarações por prefixo (origens, upstreams e comunidades)
+	LocalRegion   map[string]bool       // Países considerados locais (códigos ISO)
+	Communities   *community.Dictionary // Significado das comunidades (blackhole etc.)
+	IP2ASN        *ip2asn.Table         // Tabela prefixo→origem offline
+}
+
+// Warning is a problem found in a query or in a peer's route. Query
+// warnings that come from comparing peers name the peer they are about.
+type Warning struct {
+	Check   string `json:"check"`
+	Peer    string `json:"peer,omitempty"`
+	Message string `json:"message"`
+}
+
+// QueryWarnings checks the queried prefix and the origins seen by all peers.
+// For address queries the origins are checked against the policy of the
+// route most peers returned.
+func QueryWarnings(query parser.Query, peers []parser.Peer, opts Options) []Warning {
+	var warnings []Warning
+	checks := opts.Bogons.CheckPrefix(query.Prefix)
+	if !query.Prefix.IsValid() {
+		checks = opts.Bogons.CheckAddr(query.Addr)
+	}
+	for _, w := range checks {
+		warnings = append(warnings, Warning{Check: w.Kind, Message: w.Message})
+	}
+
+	// Origins other than the declared ones are hijack suspects
+	route := query.RoutePrefix(peers)
+	if rule, ok := opts.Policy.Lookup(route); ok && len(rule.Origins) > 0 {
+		expected := make([]int, len(rule.Origins))
+		for i, asn := range rule.Origins {
+			expected[i] = int(asn)
+		}
+		for _, origin := range analysis.Origins(peers) {
+			if !opts.Policy.UnexpectedOrigin(route, origin.Origin, origin.HasOrigin) {
+				continue
+			}
+			name := "no origin (AS_SET)"
+			if origin.HasOrigin {
+				name = fmt.Sprintf("AS%d", origin.Origin)
+			}
+			warnings = append(warnings, Warning{
+				Check:   "hijack-suspect",
+				Message: fmt.Sprintf("%s, seen by %d of %d peers, is not an expected origin of %s (expected %s)", name, origin.Peers, len(peers), rule.Prefix, formatASNs(expected)),
+			})
+		}
+	}
+
+	// The offline prefix table expects another origin
+	if entry, ok := tableEntry(opts.IP2ASN, query); ok && len(peers) > 0 {
+		seen := false
+		for _, origin := range analysis.Origins(peers) {
+			seen = seen || (origin.HasOrigin && slices.Contains(entry.Origins, uint32(origin.Origin)))
+		}
+		if !seen {
+			expected := make([]int, len(entry.Origins))
+			for i, asn := range entry.Origins {
+				expected[i] = int(asn)
+			}
+			warnings = append(warnings, Warning{
+				Check:   "table-origin",
+				Message: fmt.Sprintf("the prefix table (--ip2asn) has %s originated by %s, which no peer sees as the origin", entry.Prefix, formatASNs(expected)),
+			})
+		}
+	}
+
+	// Paths much longer than the others in the same country
+	for _, outlier := range analysis.PathLengthStats(peers).Outliers {
+		warnings = append(warnings, Warning{
+			Check:   "long-path",
+			Peer:    outlier.Peer,
+			Message: fmt.Sprintf("%s sees a path of length %d, the median in %s is %.1f", outlier.Peer, outlier.Length, outlier.Country, outlier.CountryMedian),
+		})
+	}
+	return warnings
+}
+
+// tableEntry looks up the queried prefix, or address, in the offline
+// prefix table.
+func tableEntry(table *ip2asn.Table, query parser.Query) (ip2asn.Entry, bool) {
+	if query.Prefix.IsValid() {
+		return table.LookupPrefix(query.Prefix)
+	}
+	return table.Lookup(query.Addr)
+}
+
+// PeerWarnings checks the route seen by one peer. Prefix checks are only
+// repeated when the peer returned a prefix other than the one queried, as
+// with more-specifics or with the covering route of a queried address.
+func PeerWarnings(query parser.Query, peer *parser.Peer, opts Options) []Warning {
+	var warnings []Warning
+	if peer.Prefix.IsValid() && peer.Prefix != query.Prefix {
+		for _, w := range opts.Bogons.CheckPrefix(peer.Prefix) {
+			warnings = append(warnings, Warning{Check: w.Kind, Message: w.Message})
+		}
+	}
+	for _, w := range opts.Bogons.CheckPath(peer) {
+		warnings = append(warnings, Warning{Check: w.Kind, Message: w.Message})
+	}
+	for _, match := range opts.Communities.Alerts(peer) {
+		message := fmt.Sprintf("route tagged %s", match.Community)
+		if match.Entry.Description != "" {
+			message += ", " + match.Entry.Description
+		}
+		warnings = append(warnings, Warning{Check: match.Entry.Kind, Message: message})
+	}
+	for _, v := range PolicyViolations(query, peer, opts) {
+		warnings = append(warnings, Warning{Check: v.Kind, Message: v.Message})
+	}
+	for _, violation := range opts.IRR.CheckCones(peer) {
+		warnings = append(warnings, Warning{Check: CheckConeViolation, Message: ConeMessage(violation)})
+	}
+	if opts.Relationships != nil {
+		check := opts.Relationships.CheckPeer(peer)
+		for _, leak := range check.Leaks {
+			warnings = append(warnings, Warning{Check: CheckRouteLeak, Message: LeakMessage(check, leak)})
+		}
+	}
+	geo := analysis.AnalyzeGeo(peer, opts.LocalRegion)
+	for _, trombone := range geo.Trombones {
+		warnings = append(warnings, Warning{Check: "trombone", Message: fmt.Sprintf("path trombones %s", trombone)})
+	}
+	if geo.LeavesRegion {
+		warnings = append(warnings, Warning{Check: "leaves-region", Message: fmt.Sprintf("path leaves the local region through %s", strings.Join(geo.Outside, ", "))})
+	}
+	for _, poison := range analysis.AnalyzePrepending(peer).Poisoning {
+		warnings = append(warnings, Warning{Check: "poisoning", Message: PoisoningMessage(poison)})
+	}
+	return warnings
+}
+
+// CheckConeViolation is the warning of a hop passing on routes from outside
+// its customer cone.
+const CheckConeViolation = "cone-violation"
+
+// ConeMessage describes a hop that passed on routes from ASNs outside its
+// declared customer cone.
+func ConeMessage(violation irr.ConeViolation) string {
+	outside := make([]int, len(violation.Outside))
+	for i, asn := range violation.Outside {
+		outside[i] = int(asn)
+	}
+	return fmt.Sprintf("AS%d (%s) passed on %s, outside its customer cone: possible leak", violation.ASN, violation.Cone, formatASNs(outside))
+}
+
+// CheckRouteLeak is the warning of a valley-free or OTC violation.
+const CheckRouteLeak = "route-leak"
+
+// LeakMessage describes an AS that sent a route up or across against
+// valley-free routing or the OTC attribute.
+func LeakMessage(check asrel.PathCheck, leak asrel.Leak) string {
+	route := "a route from a provider or peer"
+	if leak.OTC {
+		route = fmt.Sprintf("a route marked OTC AS%d", check.OTC)
+	}
+	return fmt.Sprintf("AS%d sent %s to its %s AS%d", leak.ASN, route, leak.Link.Rel, leak.Link.To)
+}
+
+// PolicyViolations checks the peer's route against the declared upstreams
+// and communities of the prefix the peer returned, or of the queried one
+// when the page did not show the route prefix.
+func PolicyViolations(query parser.Query, peer *parser.Peer, opts Options) []policy.Violation {
+	prefix := peer.Prefix
+	if !prefix.IsValid() {
+		prefix = query.Prefix
+	}
+	return opts.Policy.CheckPeer(prefix, peer)
+}
+
+// PoisoningMessage describes an AS that reappears after foreign ASNs.
+func PoisoningMessage(poison analysis.Poisoning) string {
+	role := ""
+	if poison.Origin {
+		role = " (origin)"
+	}
+	message := fmt.Sprintf("AS%d%s reappears after %s: possible path poisoning", poison.ASN, role, formatASNs(poison.Between))
+	if len(poison.Tier1) > 0 {
+		var names []string
+		for _, asn := range poison.Tier1 {
+			names = append(names, fmt.Sprintf("AS%d %s", asn, analysis.Tier1[asn]))
+		}
+		message += fmt.Sprintf(", Tier-1 inserted: %s", strings.Join(names, ", "))
+	}
+	return message
+}
+
+// formatASNs renders ASNs as "AS1 AS2".
+func formatASNs(asns []int) string {
+	parts := make([]string, len(asns))
+	for i, asn := range asns {
+		parts[i] = fmt.Sprintf("AS%d", asn)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Report is the result of every query in one run.
+type Report struct {
+	Queries []Query `json:"queries"`
+}
+
+// Query is the result of one resolved query.
+type Query struct {
+	Input      string                          `json:"input"`
+	Prefix     string                          `json:"prefix,omitempty"` // Vazio para endereços
+	Address    string                          `json:"address,omitempty"`
+	Family     string                          `json:"family"`
+	Notes      []string                        `json:"notes,omitempty"` // Ajustes feitos no input
+	Error      string                          `json:"error,omitempty"`
+	Warnings   []Warning                       `json:"warnings,omitempty"`
+	Peers      []Peer                          `json:"peers"`
+	Origins    []analysis.OriginCount          `json:"origins,omitempty"`
+	PathLength *analysis.LengthStats           `json:"path_length,omitempty"`
+	Upstreams  []analysis.UpstreamDistribution `json:"upstreams,omitempty"` // Nível 1 (vizinho da origem) e 2
+	Prepending []analysis.UpstreamPrepending   `json:"prepending_by_upstream,omitempty"`
+	Geography  *analysis.GeoSummary            `json:"geography,omitempty"`
+	Consensus  *analysis.Consensus             `json:"consensus,omitempty"`
+	Anycast    *analysis.Anycast               `json:"anycast,omitempty"`
+}
+
+// label names the query in alerts: its prefix, or the queried address.
+func (q Query) label() string {
+	switch {
+	case q.Prefix != "":
+		return q.Prefix
+	case q.Address != "":
+		return q.Address
+	}
+	return q.Input
+}
+
+// Peer is the route seen by one peer.
+type Peer struct {
+	Name             string             `json:"peer"`
+	Node             string             `json:"node,omitempty"`
+	Organisation     string             `json:"organisation,omitempty"`
+	ASN              int                `json:"asn,omitempty"`
+	Address          string             `json:"address,omitempty"`
+	Country          string             `json:"country,omitempty"`
+	City             string             `json:"city,omitempty"`
+	Prefix           string             `json:"prefix,omitempty"` // Rota devolvida pelo looking glass
+	ASPath           string             `json:"as_path"`
+	PathLength       int                `json:"path_length"`
+	Origin           *int               `json:"origin,omitempty"`
+	Communities      []string           `json:"communities,omitempty"`
+	MED              string             `json:"med,omitempty"`
+	LastUpdate       string             `json:"last_update,omitempty"`
+	UpdatedAt        *time.Time         `json:"updated_at,omitempty"` // "Last update" interpretado (--lg-tz)
+	Age              string             `json:"age,omitempty"`
+	RecentlyChanged  bool               `json:"recently_changed,omitempty"`  // Alterada dentro de --recent
+	OriginValidation string             `json:"origin_validation,omitempty"` // Veredito do looking glass
+	RPKI             string             `json:"rpki,omitempty"`              // Veredito local
+	IRR              string             `json:"irr,omitempty"`
+	Warnings         []Warning          `json:"warnings,omitempty"`
+	Prepends         []analysis.Prepend `json:"prepends,omitempty"`
+	Countries        []string           `json:"countries,omitempty"`
+}
+
+// Add appends the result of one query to the report.
+func (r *Report) Add(query parser.Query, peers []parser.Peer, err error, opts Options) {
+	result := Query{
+		Input:    query.Input,
+		Family:   query.Family(),
+		Notes:    query.Warnings,
+		Warnings: QueryWarnings(query, peers, opts),
+		Peers:    []Peer{},
+	}
+	if query.Prefix.IsValid() {
+		result.Prefix = query.Prefix.String()
+	}
+	if query.Addr.IsValid() {
+		result.Address = query.Addr.String()
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	for i := range peers {
+		result.Peers = append(result.Peers, newPeer(query, &peers[i], opts))
+	}
+	result.Origins = analysis.Origins(peers)
+	if len(peers) > 0 {
+		stats := analysis.PathLengthStats(peers)
+		result.PathLength = &stats
+		result.Upstreams = []analysis.UpstreamDistribution{
+			analysis.DistributeUpstreams(peers, 1),
+			analysis.DistributeUpstreams(peers, 2),
+		}
+	}
+	result.Prepending = analysis.SummarizePrepending(peers)
+	if geo := analysis.SummarizeGeo(peers, opts.LocalRegion); geo.Peers > 0 {
+		result.Geography = &geo
+	}
+	if consensus := analysis.FindConsensus(peers); consensus.Peers > 0 {
+		result.Consensus = &consensus
+	}
+	if anycast := analysis.DetectAnycast(peers); anycast.Peers > 0 {
+		result.Anycast = &anycast
+	}
+	r.Queries = append(r.Queries, result)
+}
+
+func newPeer(query parser.Query, peer *parser.Peer, opts Options) Peer {
+	segments := peer.PathSegments()
+	path := make([]string, len(segments))
+	for i, segment := range segments {
+		path[i] = segment.String()
+	}
+
+	result := Peer{
+		Name:             peer.PeerName,
+		Node:             peer.Info.Node,
+		Organisation:     peer.Info.Organisation,
+		ASN:              peer.Info.ASN,
+		Country:          peer.Info.Country,
+		City:             peer.Info.City,
+		ASPath:           strings.Join(path, " "),
+		PathLength:       peer.PathLength(),
+		Communities:      peer.Communities,
+		MED:              strings.TrimSpace(peer.Med),
+		LastUpdate:       strings.TrimSpace(peer.LastUpdate),
+		OriginValidation: strings.TrimSpace(peer.OriginValidation),
+		Warnings:         PeerWarnings(query, peer, opts),
+		Prepends:         analysis.AnalyzePrepending(peer).Prepends,
+		Countries:        analysis.CountryPath(peer),
+	}
+	if peer.Info.Address.IsValid() {
+		result.Address = peer.Info.Address.String()
+	}
+	if peer.Prefix.IsValid() {
+		result.Prefix = peer.Prefix.String()
+	}
+	now := time.Now()
+	if age, ok := peer.Age(now); ok {
+		updatedAt := peer.UpdatedAt
+		result.UpdatedAt = &updatedAt
+		result.Age = parser.FormatAge(age)
+		result.RecentlyChanged = peer.RecentlyChanged(now, config.RecentWindow)
+	}
+	if origin, ok := peer.OriginAS(); ok {
+		result.Origin = &origin
+	}
+	if opts.VRPs != nil {
+		result.RPKI = rpki.ValidatePeer(opts.VRPs, peer).State.String()
+	}
+	if opts.IRR != nil {
+		result.IRR = opts.IRR.ValidatePeer(peer).State.String()
+	}
+	return result
+}
+
+// Warnings returns the number of warnings in the report.
+func (r *Report) Warnings() int {
+	count := 0
+	for _, query := range r.Queries {
+		count += len(query.Warnings)
+		for _, peer := range query.Peers {
+			count += len(peer.Warnings)
+		}
+	}
+	return count
+}
+
+// critical lists the checks that must never go unnoticed.
+var critical = map[string]bool{
+	community.KindBlackhole:        true,
+	community.KindGracefulShutdown: true,
+	CheckRouteLeak:                 true,
+}
+
+// Critical returns the number of blackhole, graceful shutdown and route
+// leak warnings.
+func (r *Report) Critical() int {
+	count := 0
+	for _, query := range r.Queries {
+		for _, peer := range query.Peers {
+			for _, warning := range peer.Warnings {
+				if critical[warning.Check] {
+					count++
+				}
+			}
+		}
+	}
+	return count
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// WriteAlerts writes one line per warning, or a summary when there are none.
+func (r *Report) WriteAlerts(w io.Writer) {
+	peers := 0
+	for _, query := range r.Queries {
+		peers += len(query.Peers)
+		if query.Error != "" {
+			fmt.Fprintf(w, "ERROR %s: %s\n", query.label(), query.Error)
+		}
+		for _, warning := range query.Warnings {
+			fmt.Fprintf(w, "WARNING %s [%s]: %s\n", query.label(), warning.Check, warning.Message)
+		}
+		for _, peer := range query.Peers {
+			for _, warning := range peer.Warnings {
+				fmt.Fprintf(w, "WARNING %s %s [%s]: %s\n", query.label(), peer.Name, warning.Check, warning.Message)
+			}
+		}
+	}
+	if r.Warnings() == 0 {
+		fmt.Fprintf(w, "OK %d queries, %d peers, no warnings\n", len(r.Queries), peers)
+	}
+}
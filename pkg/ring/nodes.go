@@ -0,0 +1,133 @@
+package ring
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+
+	"github.com/drksbr/lg2/pkg/parser"
+)
+
+// Node is a RING node as published by the NLNOG RING API (/1.0/nodes).
+type Node struct {
+	Hostname    string `json:"hostname"`
+	ASN         int    `json:"asn"`
+	IPv4        string `json:"ipv4"`
+	IPv6        string `json:"ipv6"`
+	CountryCode string `json:"countrycode"`
+	City        string `json:"city"`
+	Geo         string `json:"geo"`
+	Active      int    `json:"active"`
+}
+
+// NodeList indexes RING nodes by node name and router address.
+type NodeList struct {
+	byName map[string]Node
+	byAddr map[netip.Addr]Node
+}
+
+// LoadNodes reads a local copy of the RING node list. Both the full API
+// response ({"results": {"nodes": [...]}}) and a bare array of nodes are
+// accepted.
+func LoadNodes(path string) (*NodeList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read node list: %v", err)
+	}
+
+	var nodes []Node
+	if err := json.Unmarshal(data, &nodes); err != nil {
+		var response struct {
+			Results struct {
+				Nodes []Node `json:"nodes"`
+			} `json:"results"`
+		}
+		if err := json.Unmarshal(data, &response); err != nil {
+			return nil, fmt.Errorf("failed to parse node list: %v", err)
+		}
+		nodes = response.Results.Nodes
+	}
+
+	return NewNodeList(nodes), nil
+}
+
+// NewNodeList builds the indexes for the given nodes.
+func NewNodeList(nodes []Node) *NodeList {
+	list := &NodeList{
+		byName: make(map[string]Node, len(nodes)),
+		byAddr: make(map[netip.Addr]Node, len(nodes)*2),
+	}
+	for _, node := range nodes {
+		list.byName[parser.NodeName(node.Hostname)] = node
+		for _, raw := range []string{node.IPv4, node.IPv6} {
+			if addr, err := netip.ParseAddr(strings.TrimSpace(raw)); err == nil {
+				list.byAddr[addr.Unmap()] = node
+			}
+		}
+	}
+	return list
+}
+
+// Len returns the number of nodes in the list.
+func (l *NodeList) Len() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.byName)
+}
+
+// Lookup finds the node for a peer, first by node name and then by router
+// address.
+func (l *NodeList) Lookup(info parser.PeerInfo) (Node, bool) {
+	if l == nil {
+		return Node{}, false
+	}
+	if node, ok := l.byName[info.Node]; ok {
+		return node, true
+	}
+	if info.Address.IsValid() {
+		node, ok := l.byAddr[info.Address]
+		return node, ok
+	}
+	return Node{}, false
+}
+
+// EnrichPeers fills the gaps of the metadata of every peer, for every
+// path that queries the looking glass.
+func (l *NodeList) EnrichPeers(peers []parser.Peer) {
+	for i := range peers {
+		l.Enrich(&peers[i].Info)
+	}
+}
+
+// Enrich fills the gaps of the peer metadata from the node list. Values
+// parsed from the looking glass take precedence.
+func (l *NodeList) Enrich(info *parser.PeerInfo) {
+	node, ok := l.Lookup(*info)
+	if !ok {
+		return
+	}
+	if info.Node == "" {
+		info.Node = parser.NodeName(node.Hostname)
+		info.Organisation = parser.NodeOrganisation(info.Node)
+	}
+	if info.ASN == 0 {
+		info.ASN = node.ASN
+	}
+	if !info.Address.IsValid() {
+		for _, raw := range []string{node.IPv4, node.IPv6} {
+			if addr, err := netip.ParseAddr(strings.TrimSpace(raw)); err == nil {
+				info.Address = addr.Unmap()
+				break
+			}
+		}
+	}
+	if info.Country == "" {
+		info.Country = strings.ToUpper(node.CountryCode)
+	}
+	if info.City == "" {
+		info.City = node.City
+	}
+}
@@ -0,0 +1,263 @@
+package rpki
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/drksbr/lg2/pkg/parser"
+)
+
+// HopCheck is the outcome of hop(x, y) in ASPA path verification: whether y
+// is an attested provider of x.
+type HopCheck int
+
+const (
+	HopNoAttestation HopCheck = iota // x não publicou ASPA
+	HopProvider                      // y é provider (ou lateral) de x
+	HopNotProvider                   // x publicou ASPA sem y
+)
+
+// String returns the name used in the ASPA verification draft.
+func (h HopCheck) String() string {
+	switch h {
+	case HopProvider:
+		return "provider+"
+	case HopNotProvider:
+		return "not provider+"
+	default:
+		return "no attestation"
+	}
+}
+
+// ASPAState is the outcome of ASPA path verification.
+type ASPAState int
+
+const (
+	ASPANotEvaluated ASPAState = iota // Sem dados ASPA carregados
+	ASPAValid
+	ASPAInvalid
+	ASPAUnknown
+)
+
+// String returns the lower-case name of the state.
+func (s ASPAState) String() string {
+	switch s {
+	case ASPAValid:
+		return "valid"
+	case ASPAInvalid:
+		return "invalid"
+	case ASPAUnknown:
+		return "unknown"
+	default:
+		return "not evaluated"
+	}
+}
+
+// ParseASPAState normalises the ASPA state reported by the looking glass.
+func ParseASPAState(text string) ASPAState {
+	text = strings.ToLower(text)
+	switch {
+	case strings.Contains(text, "invalid"):
+		return ASPAInvalid
+	case strings.Contains(text, "valid"):
+		return ASPAValid
+	case strings.Contains(text, "unknown"), strings.Contains(text, "not found"):
+		return ASPAUnknown
+	default:
+		return ASPANotEvaluated
+	}
+}
+
+// ASPA is a validated ASPA object: the providers attested by a customer AS.
+type ASPA struct {
+	Customer  uint32
+	Providers []uint32
+}
+
+// ASPASet indexes ASPA objects by customer AS.
+type ASPASet struct {
+	providers map[uint32]map[uint32]bool
+}
+
+// NewASPASet builds the index. Objects for the same customer are merged.
+func NewASPASet(aspas []ASPA) *ASPASet {
+	set := &ASPASet{providers: make(map[uint32]map[uint32]bool, len(aspas))}
+	for _, aspa := range aspas {
+		providers := set.providers[aspa.Customer]
+		if providers == nil {
+			providers = map[uint32]bool{}
+			set.providers[aspa.Customer] = providers
+		}
+		for _, provider := range aspa.Providers {
+			providers[provider] = true
+		}
+	}
+	return set
+}
+
+// Len returns the number of customer ASes with an ASPA.
+func (s *ASPASet) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.providers)
+}
+
+// LoadASPAFile reads the "aspas" array of an rpki-client JSON export. Both
+// the current format ("customer_asid" with a "providers" list of ASNs) and
+// the older "provider_set" with per-AFI entries are accepted.
+func LoadASPAFile(path string) (*ASPASet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ASPA file: %v", err)
+	}
+
+	var export struct {
+		ASPAs []struct {
+			Customer    jsonASN   `json:"customer_asid"`
+			Providers   []jsonASN `json:"providers"`
+			ProviderSet []struct {
+				Provider jsonASN `json:"provider_asid"`
+			} `json:"provider_set"`
+		} `json:"aspas"`
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("%s: invalid ASPA JSON: %v", path, err)
+	}
+
+	aspas := make([]ASPA, 0, len(export.ASPAs))
+	for _, raw := range export.ASPAs {
+		aspa := ASPA{Customer: uint32(raw.Customer)}
+		for _, provider := range raw.Providers {
+			aspa.Providers = append(aspa.Providers, uint32(provider))
+		}
+		for _, entry := range raw.ProviderSet {
+			aspa.Providers = append(aspa.Providers, uint32(entry.Provider))
+		}
+		aspas = append(aspas, aspa)
+	}
+	return NewASPASet(aspas), nil
+}
+
+// Hop implements hop(x, y) from the ASPA verification draft.
+func (s *ASPASet) Hop(customer, provider uint32) HopCheck {
+	if s == nil {
+		return HopNoAttestation
+	}
+	providers, ok := s.providers[customer]
+	if !ok {
+		return HopNoAttestation
+	}
+	if providers[provider] {
+		return HopProvider
+	}
+	return HopNotProvider
+}
+
+// ASPAHop reports both hop checks between two adjacent ASes of a path, with
+// Lower closer to the origin.
+type ASPAHop struct {
+	Lower, Upper uint32
+	Up           HopCheck // hop(Lower, Upper): Upper é provider de Lower?
+	Down         HopCheck // hop(Upper, Lower): Lower é provider de Upper?
+}
+
+// ASPAResult is the local ASPA verification of one AS path.
+type ASPAResult struct {
+	Upstream   ASPAState // Rota recebida de customer ou peer lateral
+	Downstream ASPAState // Rota recebida de provider
+	Hops       []ASPAHop // Do origin em direção ao vizinho
+	HasASSet   bool      // AS_SET no caminho torna a rota inválida
+}
+
+// VerifyPath runs the upstream and downstream ASPA verification algorithms
+// (draft-ietf-sidrops-aspa-verification) on a path given from the neighbor
+// to the origin, with prepends already removed.
+func (s *ASPASet) VerifyPath(path []uint32, hasASSet bool) ASPAResult {
+	result := ASPAResult{HasASSet: hasASSet}
+	if hasASSet {
+		result.Upstream, result.Downstream = ASPAInvalid, ASPAInvalid
+		return result
+	}
+
+	// as[1] is the origin and as[n] the neighbor, as in the draft
+	n := len(path)
+	as := make([]uint32, n+1)
+	for i, asn := range path {
+		as[n-i] = asn
+	}
+	for i := 1; i < n; i++ {
+		result.Hops = append(result.Hops, ASPAHop{
+			Lower: as[i],
+			Upper: as[i+1],
+			Up:    s.Hop(as[i], as[i+1]),
+			Down:  s.Hop(as[i+1], as[i]),
+		})
+	}
+	up := func(i int) HopCheck { return result.Hops[i-1].Up }     // hop(as[i], as[i+1])
+	down := func(i int) HopCheck { return result.Hops[i-1].Down } // hop(as[i+1], as[i])
+
+	// Up-ramps grow from the origin, down-ramps from the neighbor. The max
+	// ramps stop at "not provider+", the min ramps at anything but
+	// "provider+".
+	maxUp, minUp, maxDown, minDown := 1, 1, 1, 1
+	for i := 1; i < n && up(i) != HopNotProvider; i++ {
+		maxUp++
+	}
+	for i := 1; i < n && up(i) == HopProvider; i++ {
+		minUp++
+	}
+	for i := n - 1; i >= 1 && down(i) != HopNotProvider; i-- {
+		maxDown++
+	}
+	for i := n - 1; i >= 1 && down(i) == HopProvider; i-- {
+		minDown++
+	}
+
+	// Upstream verification
+	switch {
+	case maxUp < n:
+		result.Upstream = ASPAInvalid
+	case minUp < n:
+		result.Upstream = ASPAUnknown
+	default:
+		result.Upstream = ASPAValid
+	}
+
+	// Downstream verification
+	switch {
+	case n <= 2:
+		result.Downstream = ASPAValid
+	case maxUp+maxDown < n:
+		result.Downstream = ASPAInvalid
+	case minUp+minDown < n:
+		result.Downstream = ASPAUnknown
+	default:
+		result.Downstream = ASPAValid
+	}
+	return result
+}
+
+// VerifyPeer runs ASPA verification on the AS path seen by a peer.
+func (s *ASPASet) VerifyPeer(peer *parser.Peer) ASPAResult {
+	var path []uint32
+	hasASSet := false
+	for _, segment := range peer.PathSegments() {
+		if segment.Type.IsConfed() {
+			continue
+		}
+		if segment.Type == parser.SegmentSet {
+			hasASSet = true
+			continue
+		}
+		for _, as := range segment.ASNs {
+			asn := uint32(as.AsNumber)
+			if len(path) == 0 || path[len(path)-1] != asn {
+				path = append(path, asn)
+			}
+		}
+	}
+	return s.VerifyPath(path, hasASSet)
+}
@@ -0,0 +1,182 @@
+package rpki
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+
+	"github.com/drksbr/lg2/pkg/parser"
+)
+
+// maxLengthDepth bounds how many levels of more-specifics are checked when
+// deciding whether a maxLength above the prefix length is safe.
+const maxLengthDepth = 8
+
+// Observation is an announcement seen by the looking glass peers.
+type Observation struct {
+	Prefix    netip.Prefix
+	Origin    uint32
+	HasOrigin bool // false quando o caminho termina em AS_SET
+	Peers     int  // Quantos peers viram o anúncio
+}
+
+// String renders the observation as "prefix ASn".
+func (o Observation) String() string {
+	if !o.HasOrigin {
+		return fmt.Sprintf("%s (no origin)", o.Prefix)
+	}
+	return fmt.Sprintf("%s AS%d", o.Prefix, o.Origin)
+}
+
+// Observe aggregates the routes seen by the peers into announcements, keyed
+// on the prefix each peer returned. Routes without a prefix are skipped.
+func Observe(peers []parser.Peer) []Observation {
+	type key struct {
+		prefix    netip.Prefix
+		origin    uint32
+		hasOrigin bool
+	}
+	counts := map[key]int{}
+	for i := range peers {
+		if !peers[i].Prefix.IsValid() {
+			continue
+		}
+		origin, ok := peers[i].OriginAS()
+		counts[key{peers[i].Prefix, uint32(origin), ok}]++
+	}
+
+	observations := make([]Observation, 0, len(counts))
+	for k, n := range counts {
+		observations = append(observations, Observation{Prefix: k.prefix, Origin: k.origin, HasOrigin: k.hasOrigin, Peers: n})
+	}
+	sort.Slice(observations, func(i, j int) bool {
+		a, b := observations[i], observations[j]
+		if c := a.Prefix.Addr().Compare(b.Prefix.Addr()); c != 0 {
+			return c < 0
+		}
+		if a.Prefix.Bits() != b.Prefix.Bits() {
+			return a.Prefix.Bits() < b.Prefix.Bits()
+		}
+		return a.Origin < b.Origin
+	})
+	return observations
+}
+
+// Recommendation is a proposed ROA set and its effect on what was observed.
+type Recommendation struct {
+	ROAs        []VRP
+	Invalidated []Observation // Anúncios que os ROAs propostos invalidariam
+	Warnings    []string
+}
+
+// RecommendROAs proposes a minimal ROA set covering the observed
+// announcements. Following RFC 9319, maxLength is only raised above the
+// prefix length when every more-specific up to that length is announced by
+// the same origin. When authorized is not empty, only those origins get
+// ROAs and announcements from other origins are reported as invalidated.
+func RecommendROAs(observations []Observation, authorized map[uint32]bool) Recommendation {
+	var rec Recommendation
+
+	// Announced prefixes per authorized origin
+	announced := map[uint32]map[netip.Prefix]bool{}
+	for _, obs := range observations {
+		switch {
+		case !obs.HasOrigin:
+			rec.Warnings = append(rec.Warnings, fmt.Sprintf("%s has no origin AS (AS_SET) in %d peers: it will be invalid under any ROA", obs.Prefix, obs.Peers))
+			continue
+		case obs.Origin == 0:
+			continue
+		case len(authorized) > 0 && !authorized[obs.Origin]:
+			continue
+		}
+		if announced[obs.Origin] == nil {
+			announced[obs.Origin] = map[netip.Prefix]bool{}
+		}
+		announced[obs.Origin][obs.Prefix] = true
+	}
+
+	for origin, prefixes := range announced {
+		sorted := make([]netip.Prefix, 0, len(prefixes))
+		for prefix := range prefixes {
+			sorted = append(sorted, prefix)
+		}
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].Bits() < sorted[j].Bits() })
+
+		var roas []VRP
+		for _, prefix := range sorted {
+			covered := false
+			for _, roa := range roas {
+				if roa.Covers(prefix) && prefix.Bits() <= roa.MaxLength {
+					covered = true
+					break
+				}
+			}
+			if !covered {
+				roas = append(roas, VRP{Prefix: prefix, MaxLength: safeMaxLength(prefix, prefixes), ASN: origin})
+			}
+		}
+		rec.ROAs = append(rec.ROAs, roas...)
+	}
+	SortVRPs(rec.ROAs)
+
+	// Multiple origins for the same prefix are kept but deserve a review
+	origins := map[netip.Prefix][]uint32{}
+	for _, roa := range rec.ROAs {
+		origins[roa.Prefix] = append(origins[roa.Prefix], roa.ASN)
+	}
+	for _, roa := range rec.ROAs {
+		if list := origins[roa.Prefix]; len(list) > 1 && list[0] == roa.ASN {
+			rec.Warnings = append(rec.Warnings, fmt.Sprintf("%s is announced by %d origins %v: make sure all of them are intended", roa.Prefix, len(list), list))
+		}
+	}
+
+	// Check every observed announcement against the proposed set
+	proposed := NewVRPSet(rec.ROAs)
+	for _, obs := range observations {
+		if proposed.Validate(obs.Prefix, obs.Origin, obs.HasOrigin).State == StateInvalid {
+			rec.Invalidated = append(rec.Invalidated, obs)
+		}
+	}
+	return rec
+}
+
+// safeMaxLength returns the largest length L such that every more-specific
+// of prefix down to L is announced.
+func safeMaxLength(prefix netip.Prefix, announced map[netip.Prefix]bool) int {
+	maxLength := prefix.Bits()
+	for length := prefix.Bits() + 1; length <= prefix.Addr().BitLen() && length-prefix.Bits() <= maxLengthDepth; length++ {
+		for _, sub := range subPrefixes(prefix, length) {
+			if !announced[sub] {
+				return maxLength
+			}
+		}
+		maxLength = length
+	}
+	return maxLength
+}
+
+// subPrefixes lists every sub-prefix of prefix with the given length.
+func subPrefixes(prefix netip.Prefix, length int) []netip.Prefix {
+	count := 1 << (length - prefix.Bits())
+	subs := make([]netip.Prefix, 0, count)
+	addr := prefix.Masked().Addr()
+	for i := 0; i < count; i++ {
+		subs = append(subs, netip.PrefixFrom(addr, length))
+		addr = nextPrefixAddr(addr, length)
+	}
+	return subs
+}
+
+// nextPrefixAddr returns the first address after the /length block that
+// starts at addr.
+func nextPrefixAddr(addr netip.Addr, length int) netip.Addr {
+	bytes := addr.AsSlice()
+	bit := length - 1
+	for carry := true; carry && bit >= 0; bit-- {
+		index, mask := bit/8, byte(1)<<(7-bit%8)
+		bytes[index] ^= mask
+		carry = bytes[index]&mask == 0
+	}
+	next, _ := netip.AddrFromSlice(bytes)
+	return next
+}
@@ -0,0 +1,394 @@
+package rpki
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+)
+
+// Default RTR timers (RFC 8210 section 6) used until the cache sends its own.
+const (
+	defaultRefresh = time.Hour
+	defaultRetry   = 10 * time.Minute
+	defaultExpire  = 2 * time.Hour
+	dialTimeout    = 10 * time.Second
+)
+
+// errVersionDowngrade asks Run to reconnect speaking RTR version 0.
+var errVersionDowngrade = errors.New("rtr: cache does not support version 1, falling back to version 0")
+
+// RTRClient keeps a live VRP table synchronised from an RPKI-to-Router cache
+// (RFC 6810 / RFC 8210). It implements Validator; until the first full
+// synchronisation, and once the table is older than the expire interval
+// without a successful refresh, every route validates as StateUnknown.
+type RTRClient struct {
+	addr string
+
+	mu        sync.RWMutex
+	version   uint8
+	table     *VRPSet
+	current   map[VRP]struct{}
+	session   uint16
+	serial    uint32
+	synced    bool
+	refresh   time.Duration
+	retry     time.Duration
+	expire    time.Duration
+	lastSync  time.Time
+	lastError error
+
+	updates chan struct{}
+}
+
+// NewRTRClient creates a client for the cache at addr ("host:port").
+func NewRTRClient(addr string) *RTRClient {
+	return &RTRClient{
+		addr:    addr,
+		version: 1,
+		refresh: defaultRefresh,
+		retry:   defaultRetry,
+		expire:  defaultExpire,
+		updates: make(chan struct{}, 1),
+	}
+}
+
+var _ Feed = (*RTRClient)(nil)
+
+// Updates returns a channel that receives a value after every change to the
+// VRP table. The TUI re-validates and redraws its peers on each one.
+func (c *RTRClient) Updates() <-chan struct{} {
+	return c.updates
+}
+
+// Validate implements Validator against the current VRP table.
+func (c *RTRClient) Validate(prefix netip.Prefix, origin uint32, hasOrigin bool) Validation {
+	table := c.Table()
+	if table == nil {
+		return Validation{State: StateUnknown}
+	}
+	return table.Validate(prefix, origin, hasOrigin)
+}
+
+// Table returns a snapshot of the current VRP table (nil before the first
+// synchronisation and after the table expires).
+func (c *RTRClient) Table() *VRPSet {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if !c.synced || c.expiredLocked() {
+		return nil
+	}
+	return c.table
+}
+
+// expiredLocked reports whether the table outlived the expire interval
+// (RFC 8210 section 6). Must hold c.mu.
+func (c *RTRClient) expiredLocked() bool {
+	return c.synced && time.Since(c.lastSync) > c.expire
+}
+
+// dropExpired discards an expired table, so the client starts over with a
+// Reset Query. It reports whether the table was dropped.
+func (c *RTRClient) dropExpired() bool {
+	c.mu.Lock()
+	expired := c.expiredLocked()
+	if expired {
+		c.table, c.current, c.synced = nil, nil, false
+	}
+	c.mu.Unlock()
+	if expired {
+		c.notify()
+	}
+	return expired
+}
+
+// Status describes the synchronisation state for display.
+func (c *RTRClient) Status() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	switch {
+	case c.expiredLocked():
+		return fmt.Sprintf("RTR %s serial %d expired, no refresh since %s", c.addr, c.serial, c.lastSync.Format(time.DateTime))
+	case c.synced && c.lastError != nil:
+		return fmt.Sprintf("RTR %s serial %d, %d VRPs (stale: %v)", c.addr, c.serial, c.table.Len(), c.lastError)
+	case c.synced:
+		return fmt.Sprintf("RTR %s serial %d, %d VRPs", c.addr, c.serial, c.table.Len())
+	case c.lastError != nil:
+		return fmt.Sprintf("RTR %s not synced: %v", c.addr, c.lastError)
+	default:
+		return fmt.Sprintf("RTR %s syncing", c.addr)
+	}
+}
+
+// WaitSynced blocks until the first full table has been received.
+func (c *RTRClient) WaitSynced(ctx context.Context) error {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		c.mu.RLock()
+		synced, lastError := c.synced, c.lastError
+		c.mu.RUnlock()
+		if synced {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			if lastError != nil {
+				return lastError
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run connects to the cache and keeps the table up to date until ctx is
+// cancelled, reconnecting after the retry interval when the session fails.
+// The table is dropped once it is older than the expire interval.
+func (c *RTRClient) Run(ctx context.Context) error {
+	for {
+		err := c.runSession(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		c.dropExpired()
+
+		c.mu.Lock()
+		c.lastError = err
+		retry := c.retry
+		if errors.Is(err, errVersionDowngrade) {
+			retry = 0
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retry):
+		}
+	}
+}
+
+// runSession handles one transport session with the cache.
+func (c *RTRClient) runSession(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: dialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Unblock the reader when the context ends
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	// The reader stops once the session is over, even with a PDU pending
+	done := make(chan struct{})
+	defer close(done)
+	pdus := make(chan pdu)
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			p, err := readPDU(conn)
+			if err != nil {
+				readErr <- err
+				return
+			}
+			select {
+			case pdus <- p:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	c.mu.RLock()
+	version, hasSession, session, serial := c.version, c.synced, c.session, c.serial
+	c.mu.RUnlock()
+
+	// Resume the previous session when possible, otherwise ask for everything
+	resetting := !hasSession
+	if resetting {
+		err = c.send(conn, encodePDU(version, pduResetQuery, 0, nil))
+	} else {
+		err = c.send(conn, encodeSerial(version, pduSerialQuery, session, serial))
+	}
+	if err != nil {
+		return err
+	}
+
+	var working map[VRP]struct{}
+	inResponse := false
+	refresh := time.NewTimer(c.refreshInterval())
+	defer refresh.Stop()
+	expire := time.NewTicker(time.Minute)
+	defer expire.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case err := <-readErr:
+			return err
+
+		case <-expire.C:
+			// A live session whose refreshes go unanswered still expires
+			if c.dropExpired() && !inResponse {
+				resetting = true
+				if err := c.send(conn, encodePDU(version, pduResetQuery, 0, nil)); err != nil {
+					return err
+				}
+			}
+
+		case <-refresh.C:
+			// Poll the cache in case a Serial Notify was missed
+			if !inResponse {
+				c.mu.RLock()
+				synced, session, serial := c.synced, c.session, c.serial
+				c.mu.RUnlock()
+				query := encodeSerial(version, pduSerialQuery, session, serial)
+				if !synced {
+					resetting = true
+					query = encodePDU(version, pduResetQuery, 0, nil)
+				}
+				if err := c.send(conn, query); err != nil {
+					return err
+				}
+			}
+			refresh.Reset(c.refreshInterval())
+
+		case p := <-pdus:
+			if p.Type != pduErrorReport && p.Version != version {
+				return fmt.Errorf("rtr: unexpected version %d from cache (speaking %d)", p.Version, version)
+			}
+
+			switch p.Type {
+			case pduSerialNotify:
+				c.mu.RLock()
+				synced, session, serial := c.synced, c.session, c.serial
+				c.mu.RUnlock()
+				if !inResponse && synced && p.Serial != serial {
+					if err := c.send(conn, encodeSerial(version, pduSerialQuery, session, serial)); err != nil {
+						return err
+					}
+				}
+
+			case pduCacheResponse:
+				c.mu.RLock()
+				if !resetting && c.synced && p.Session != c.session {
+					// The cache restarted with a new session: start over
+					resetting = true
+				}
+				working = make(map[VRP]struct{}, len(c.current))
+				if !resetting {
+					for vrp := range c.current {
+						working[vrp] = struct{}{}
+					}
+				}
+				c.mu.RUnlock()
+				inResponse = true
+
+			case pduIPv4Prefix, pduIPv6Prefix:
+				if !inResponse {
+					return fmt.Errorf("rtr: prefix PDU outside of a cache response")
+				}
+				if p.Announce {
+					working[p.VRP] = struct{}{}
+				} else {
+					delete(working, p.VRP)
+				}
+
+			case pduRouterKey:
+				// BGPsec router keys are not used for origin validation
+
+			case pduEndOfData:
+				if !inResponse {
+					return fmt.Errorf("rtr: end of data outside of a cache response")
+				}
+				c.commit(working, p)
+				working = nil
+				inResponse = false
+				resetting = false
+				refresh.Reset(c.refreshInterval())
+
+			case pduCacheReset:
+				// The cache cannot serve the delta: fetch the whole table
+				resetting = true
+				if err := c.send(conn, encodePDU(version, pduResetQuery, 0, nil)); err != nil {
+					return err
+				}
+
+			case pduErrorReport:
+				switch p.Session {
+				case rtrErrUnsupportedVersion:
+					if version > 0 {
+						c.mu.Lock()
+						c.version = 0
+						c.mu.Unlock()
+						return errVersionDowngrade
+					}
+				case rtrErrNoData:
+					return fmt.Errorf("rtr: cache has no data available yet")
+				}
+				return fmt.Errorf("rtr: cache error %d: %s", p.Session, p.ErrorText)
+
+			default:
+				return fmt.Errorf("rtr: unsupported PDU type %d", p.Type)
+			}
+		}
+	}
+}
+
+// commit installs the table received up to an End of Data PDU.
+func (c *RTRClient) commit(working map[VRP]struct{}, eod pdu) {
+	vrps := make([]VRP, 0, len(working))
+	for vrp := range working {
+		vrps = append(vrps, vrp)
+	}
+	table := NewVRPSet(vrps)
+
+	c.mu.Lock()
+	c.current = working
+	c.table = table
+	c.session = eod.Session
+	c.serial = eod.Serial
+	c.synced = true
+	c.lastSync = time.Now()
+	c.lastError = nil
+	if eod.Refresh > 0 {
+		c.refresh = time.Duration(eod.Refresh) * time.Second
+	}
+	if eod.Retry > 0 {
+		c.retry = time.Duration(eod.Retry) * time.Second
+	}
+	if eod.Expire > 0 {
+		c.expire = time.Duration(eod.Expire) * time.Second
+	}
+	c.mu.Unlock()
+	c.notify()
+}
+
+// notify signals a change of the VRP table without blocking; one pending
+// notification is enough.
+func (c *RTRClient) notify() {
+	select {
+	case c.updates <- struct{}{}:
+	default:
+	}
+}
+
+func (c *RTRClient) refreshInterval() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.refresh
+}
+
+func (c *RTRClient) send(conn net.Conn, data []byte) error {
+	_ = conn.SetWriteDeadline(time.Now().Add(dialTimeout))
+	_, err := conn.Write(data)
+	return err
+}
@@ -0,0 +1,151 @@
+package rpki
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/netip"
+)
+
+// RTR PDU types (RFC 6810 section 5, RFC 8210 section 5).
+const (
+	pduSerialNotify  uint8 = 0
+	pduSerialQuery   uint8 = 1
+	pduResetQuery    uint8 = 2
+	pduCacheResponse uint8 = 3
+	pduIPv4Prefix    uint8 = 4
+	pduIPv6Prefix    uint8 = 6
+	pduEndOfData     uint8 = 7
+	pduCacheReset    uint8 = 8
+	pduRouterKey     uint8 = 9
+	pduErrorReport   uint8 = 10
+)
+
+// RTR error codes (RFC 8210 section 12).
+const (
+	rtrErrNoData             uint16 = 2
+	rtrErrInvalidRequest     uint16 = 3
+	rtrErrUnsupportedVersion uint16 = 4
+)
+
+// maxPDULength bounds the size of a PDU read from the wire.
+const maxPDULength = 64 * 1024
+
+// rtrHeaderLength is the size of the common PDU header.
+const rtrHeaderLength = 8
+
+// pdu is a decoded RTR PDU. Only the fields relevant to its type are set.
+type pdu struct {
+	Version   uint8
+	Type      uint8
+	Session   uint16 // Session ID, ou código de erro no Error Report
+	Serial    uint32
+	Announce  bool
+	VRP       VRP
+	Refresh   uint32
+	Retry     uint32
+	Expire    uint32
+	ErrorText string
+}
+
+// readPDU reads and decodes one PDU.
+func readPDU(r io.Reader) (pdu, error) {
+	header := make([]byte, rtrHeaderLength)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return pdu{}, err
+	}
+
+	p := pdu{
+		Version: header[0],
+		Type:    header[1],
+		Session: binary.BigEndian.Uint16(header[2:4]),
+	}
+	length := binary.BigEndian.Uint32(header[4:8])
+	if length < rtrHeaderLength || length > maxPDULength {
+		return p, fmt.Errorf("rtr: invalid PDU length %d", length)
+	}
+	body := make([]byte, length-rtrHeaderLength)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return p, err
+	}
+
+	short := func(want int) error {
+		if len(body) < want {
+			return fmt.Errorf("rtr: PDU type %d too short (%d bytes)", p.Type, length)
+		}
+		return nil
+	}
+
+	switch p.Type {
+	case pduSerialNotify, pduSerialQuery:
+		if err := short(4); err != nil {
+			return p, err
+		}
+		p.Serial = binary.BigEndian.Uint32(body)
+
+	case pduIPv4Prefix, pduIPv6Prefix:
+		addrLen := 4
+		if p.Type == pduIPv6Prefix {
+			addrLen = 16
+		}
+		if err := short(4 + addrLen + 4); err != nil {
+			return p, err
+		}
+		addr, _ := netip.AddrFromSlice(body[4 : 4+addrLen])
+		prefix, err := addr.Prefix(int(body[1]))
+		if err != nil || int(body[1]) > addr.BitLen() {
+			return p, fmt.Errorf("rtr: invalid prefix length %d", body[1])
+		}
+		if body[2] < body[1] || int(body[2]) > addr.BitLen() {
+			return p, fmt.Errorf("rtr: invalid max length %d for %s", body[2], prefix)
+		}
+		p.Announce = body[0]&1 == 1
+		p.VRP = VRP{
+			Prefix:    prefix,
+			MaxLength: int(body[2]),
+			ASN:       binary.BigEndian.Uint32(body[4+addrLen:]),
+		}
+
+	case pduEndOfData:
+		if err := short(4); err != nil {
+			return p, err
+		}
+		p.Serial = binary.BigEndian.Uint32(body)
+		if p.Version >= 1 && len(body) >= 16 {
+			p.Refresh = binary.BigEndian.Uint32(body[4:])
+			p.Retry = binary.BigEndian.Uint32(body[8:])
+			p.Expire = binary.BigEndian.Uint32(body[12:])
+		}
+
+	case pduErrorReport:
+		if err := short(4); err != nil {
+			return p, err
+		}
+		// The lengths are compared as uint32 before becoming offsets: on
+		// 32-bit targets a huge length would overflow int
+		encapsulated := binary.BigEndian.Uint32(body)
+		if len(body) >= 8 && encapsulated <= uint32(len(body)-8) {
+			offset := 4 + int(encapsulated)
+			textLen := binary.BigEndian.Uint32(body[offset:])
+			if textLen <= uint32(len(body)-offset-4) {
+				p.ErrorText = string(body[offset+4 : offset+4+int(textLen)])
+			}
+		}
+	}
+
+	return p, nil
+}
+
+// encodePDU builds a PDU with the common header followed by body.
+func encodePDU(version, pduType uint8, session uint16, body []byte) []byte {
+	buf := make([]byte, rtrHeaderLength, rtrHeaderLength+len(body))
+	buf[0] = version
+	buf[1] = pduType
+	binary.BigEndian.PutUint16(buf[2:], session)
+	binary.BigEndian.PutUint32(buf[4:], uint32(rtrHeaderLength+len(body)))
+	return append(buf, body...)
+}
+
+func encodeSerial(version, pduType uint8, session uint16, serial uint32) []byte {
+	return encodePDU(version, pduType, session, binary.BigEndian.AppendUint32(nil, serial))
+}
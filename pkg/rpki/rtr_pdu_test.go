@@ -0,0 +1,35 @@
+package rpki
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestReadPDUErrorReportLengths(t *testing.T) {
+	tests := []struct {
+		name         string
+		encapsulated uint32
+		textLen      uint32
+		want         string
+	}{
+		{"valid", 0, 4, "oops"},
+		{"huge encapsulated length", 0xffffffff, 4, ""},
+		{"huge text length", 0, 0xffffffff, ""},
+		{"text past the end", 0, 5, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := binary.BigEndian.AppendUint32(nil, tt.encapsulated)
+			body = binary.BigEndian.AppendUint32(body, tt.textLen)
+			body = append(body, "oops"...)
+			p, err := readPDU(bytes.NewReader(encodePDU(1, pduErrorReport, rtrErrInvalidRequest, body)))
+			if err != nil {
+				t.Fatalf("readPDU: %v", err)
+			}
+			if p.ErrorText != tt.want {
+				t.Errorf("ErrorText = %q, want %q", p.ErrorText, tt.want)
+			}
+		})
+	}
+}
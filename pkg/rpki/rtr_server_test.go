@@ -0,0 +1,292 @@
+package rpki
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+)
+
+// rtrServer is a minimal RPKI-to-Router cache serving a static VRP set. It
+// answers Reset Queries with the full table and Serial Queries for an older
+// serial with a Cache Reset, since it keeps no history.
+type rtrServer struct {
+	mu       sync.Mutex
+	vrps     []VRP
+	session  uint16
+	serial   uint32
+	refresh  uint32             // Refresh Interval enviado no End of Data
+	expire   uint32             // Expire Interval enviado no End of Data
+	conns    map[net.Conn]uint8 // conexões e a versão negociada
+	listener net.Listener
+}
+
+// startRTRServer serves vrps on a local port until the test ends.
+func startRTRServer(t *testing.T, vrps []VRP) *rtrServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &rtrServer{vrps: vrps, session: 42, serial: 1, refresh: 3600, expire: 7200, conns: map[net.Conn]uint8{}, listener: listener}
+	go s.serve()
+	t.Cleanup(s.close)
+	return s
+}
+
+func (s *rtrServer) addr() string {
+	return s.listener.Addr().String()
+}
+
+// setVRPs replaces the served table, bumps the serial and sends a Serial
+// Notify to every connected router.
+func (s *rtrServer) setVRPs(vrps []VRP) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vrps = vrps
+	s.serial++
+	for conn, version := range s.conns {
+		_, _ = conn.Write(encodeSerial(version, pduSerialNotify, s.session, s.serial))
+	}
+}
+
+// close stops accepting routers and drops the connected ones.
+func (s *rtrServer) close() {
+	s.listener.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+func (s *rtrServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *rtrServer) handle(conn net.Conn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	for {
+		p, err := readPDU(conn)
+		if err != nil {
+			return
+		}
+		if p.Version > 1 {
+			_, _ = conn.Write(encodeErrorReport(1, rtrErrUnsupportedVersion, "only RTR versions 0 and 1 are supported"))
+			return
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = p.Version
+		var reply []byte
+		switch p.Type {
+		case pduResetQuery:
+			reply = encodePDU(p.Version, pduCacheResponse, s.session, nil)
+			for _, vrp := range s.vrps {
+				reply = append(reply, encodePrefix(p.Version, vrp, true)...)
+			}
+			reply = append(reply, encodeEndOfData(p.Version, s.session, s.serial, s.refresh, 600, s.expire)...)
+		case pduSerialQuery:
+			if p.Session == s.session && p.Serial == s.serial {
+				reply = encodePDU(p.Version, pduCacheResponse, s.session, nil)
+				reply = append(reply, encodeEndOfData(p.Version, s.session, s.serial, s.refresh, 600, s.expire)...)
+			} else {
+				reply = encodePDU(p.Version, pduCacheReset, 0, nil)
+			}
+		default:
+			reply = encodeErrorReport(p.Version, rtrErrInvalidRequest, "unexpected PDU from router")
+		}
+		// Written under the lock so a Serial Notify never splits the reply
+		_, err = conn.Write(reply)
+		s.mu.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+func encodePrefix(version uint8, vrp VRP, announce bool) []byte {
+	pduType := pduIPv6Prefix
+	if vrp.Prefix.Addr().Is4() {
+		pduType = pduIPv4Prefix
+	}
+	var flags byte
+	if announce {
+		flags = 1
+	}
+	body := []byte{flags, byte(vrp.Prefix.Bits()), byte(vrp.MaxLength), 0}
+	body = append(body, vrp.Prefix.Addr().AsSlice()...)
+	body = binary.BigEndian.AppendUint32(body, vrp.ASN)
+	return encodePDU(version, pduType, 0, body)
+}
+
+func encodeEndOfData(version uint8, session uint16, serial, refresh, retry, expire uint32) []byte {
+	body := binary.BigEndian.AppendUint32(nil, serial)
+	if version >= 1 {
+		body = binary.BigEndian.AppendUint32(body, refresh)
+		body = binary.BigEndian.AppendUint32(body, retry)
+		body = binary.BigEndian.AppendUint32(body, expire)
+	}
+	return encodePDU(version, pduEndOfData, session, body)
+}
+
+func encodeErrorReport(version uint8, code uint16, text string) []byte {
+	body := binary.BigEndian.AppendUint32(nil, 0)
+	body = binary.BigEndian.AppendUint32(body, uint32(len(text)))
+	body = append(body, text...)
+	return encodePDU(version, pduErrorReport, code, body)
+}
+
+var (
+	testVRP   = VRP{Prefix: netip.MustParsePrefix("192.0.2.0/24"), MaxLength: 24, ASN: 64500}
+	testRoute = netip.MustParsePrefix("192.0.2.0/24")
+)
+
+// runClient starts a client against the server and waits for the first
+// synchronisation.
+func runClient(t *testing.T, s *rtrServer) *RTRClient {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	client := NewRTRClient(s.addr())
+	done := make(chan error, 1)
+	go func() { done <- client.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	wait, stop := context.WithTimeout(ctx, 5*time.Second)
+	defer stop()
+	if err := client.WaitSynced(wait); err != nil {
+		t.Fatalf("WaitSynced: %v", err)
+	}
+	return client
+}
+
+// waitUpdate waits for the next change of the client's table.
+func waitUpdate(t *testing.T, client *RTRClient) {
+	t.Helper()
+	select {
+	case <-client.Updates():
+	case <-time.After(5 * time.Second):
+		t.Fatal("no table update")
+	}
+}
+
+func TestRTRClientReset(t *testing.T) {
+	s := startRTRServer(t, []VRP{testVRP})
+	client := runClient(t, s)
+
+	if got := client.Validate(testRoute, 64500, true).State; got != StateValid {
+		t.Errorf("AS64500 = %v, want valid", got)
+	}
+	if got := client.Validate(testRoute, 64501, true).State; got != StateInvalid {
+		t.Errorf("AS64501 = %v, want invalid", got)
+	}
+	if got := client.Table().Len(); got != 1 {
+		t.Errorf("table has %d VRPs, want 1", got)
+	}
+}
+
+func TestRTRClientSerialQuery(t *testing.T) {
+	s := startRTRServer(t, []VRP{testVRP})
+	s.refresh = 1
+	client := runClient(t, s)
+	<-client.Updates() // Sincronização inicial
+
+	// A poll for the current serial gets an empty response
+	waitUpdate(t, client)
+
+	if got := client.Validate(testRoute, 64500, true).State; got != StateValid {
+		t.Errorf("after serial query AS64500 = %v, want valid", got)
+	}
+	client.mu.RLock()
+	serial := client.serial
+	client.mu.RUnlock()
+	if serial != 1 {
+		t.Errorf("serial = %d, want 1", serial)
+	}
+}
+
+func TestRTRClientCacheReset(t *testing.T) {
+	s := startRTRServer(t, []VRP{testVRP})
+	client := runClient(t, s)
+	<-client.Updates()
+
+	// The notify leads to a Serial Query the server cannot serve as a
+	// delta: it answers with a Cache Reset and the client reloads
+	moved := VRP{Prefix: testRoute, MaxLength: 24, ASN: 64501}
+	s.setVRPs([]VRP{moved})
+	waitUpdate(t, client)
+
+	if got := client.Validate(testRoute, 64501, true).State; got != StateValid {
+		t.Errorf("AS64501 = %v, want valid", got)
+	}
+	if got := client.Validate(testRoute, 64500, true).State; got != StateInvalid {
+		t.Errorf("AS64500 = %v, want invalid", got)
+	}
+	client.mu.RLock()
+	serial := client.serial
+	client.mu.RUnlock()
+	if serial != 2 {
+		t.Errorf("serial = %d, want 2", serial)
+	}
+}
+
+func TestRTRClientExpire(t *testing.T) {
+	s := startRTRServer(t, []VRP{testVRP})
+	s.expire = 1
+	client := runClient(t, s)
+
+	// Without the cache the table lasts until the expire interval
+	s.close()
+	if got := client.Validate(testRoute, 64500, true).State; got != StateValid {
+		t.Errorf("before expiring AS64500 = %v, want valid", got)
+	}
+	time.Sleep(1100 * time.Millisecond)
+	if got := client.Validate(testRoute, 64500, true).State; got != StateUnknown {
+		t.Errorf("after expiring AS64500 = %v, want unknown", got)
+	}
+	if client.Table() != nil {
+		t.Error("expired table still returned")
+	}
+}
+
+func TestRTRClientStopsWithContext(t *testing.T) {
+	s := startRTRServer(t, []VRP{testVRP})
+	ctx, cancel := context.WithCancel(context.Background())
+	client := NewRTRClient(s.addr())
+	done := make(chan error, 1)
+	go func() { done <- client.Run(ctx) }()
+	wait, stop := context.WithTimeout(ctx, 5*time.Second)
+	defer stop()
+	if err := client.WaitSynced(wait); err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Run = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+}
@@ -0,0 +1,198 @@
+package rpki
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SLURM is a local exception file (RFC 8416). Only the ROA parts are used:
+// prefix filters remove VRPs and prefix assertions add them.
+type SLURM struct {
+	Filters    []PrefixFilter
+	Assertions []VRP
+}
+
+// PrefixFilter removes the VRPs matching its prefix and/or ASN. A filter
+// prefix matches VRPs for the same prefix or a more specific one.
+type PrefixFilter struct {
+	Prefix  netip.Prefix // inválido se o filtro é só por ASN
+	ASN     uint32
+	HasASN  bool
+	Comment string
+}
+
+// Matches reports whether the filter removes the VRP.
+func (f PrefixFilter) Matches(vrp VRP) bool {
+	if f.Prefix.IsValid() && !(f.Prefix.Bits() <= vrp.Prefix.Bits() && f.Prefix.Contains(vrp.Prefix.Addr())) {
+		return false
+	}
+	if f.HasASN && f.ASN != vrp.ASN {
+		return false
+	}
+	return f.Prefix.IsValid() || f.HasASN
+}
+
+// slurmFile mirrors the RFC 8416 JSON layout.
+type slurmFile struct {
+	SlurmVersion            int `json:"slurmVersion"`
+	ValidationOutputFilters struct {
+		PrefixFilters []slurmPrefix `json:"prefixFilters"`
+		BgpsecFilters []any         `json:"bgpsecFilters"`
+	} `json:"validationOutputFilters"`
+	LocallyAddedAssertions struct {
+		PrefixAssertions []slurmPrefix `json:"prefixAssertions"`
+		BgpsecAssertions []any         `json:"bgpsecAssertions"`
+	} `json:"locallyAddedAssertions"`
+}
+
+type slurmPrefix struct {
+	ASN             *uint32 `json:"asn,omitempty"`
+	Prefix          string  `json:"prefix,omitempty"`
+	MaxPrefixLength *int    `json:"maxPrefixLength,omitempty"`
+	Comment         string  `json:"comment,omitempty"`
+}
+
+// LoadSLURMFile reads an RFC 8416 SLURM file.
+func LoadSLURMFile(path string) (*SLURM, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SLURM file: %v", err)
+	}
+
+	var file slurmFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: invalid SLURM JSON: %v", path, err)
+	}
+	if file.SlurmVersion != 1 {
+		return nil, fmt.Errorf("%s: unsupported slurmVersion %d", path, file.SlurmVersion)
+	}
+
+	slurm := &SLURM{}
+	for i, raw := range file.ValidationOutputFilters.PrefixFilters {
+		filter := PrefixFilter{Comment: raw.Comment}
+		if raw.Prefix != "" {
+			prefix, err := netip.ParsePrefix(raw.Prefix)
+			if err != nil {
+				return nil, fmt.Errorf("%s: prefix filter %d: invalid prefix %q", path, i, raw.Prefix)
+			}
+			filter.Prefix = prefix.Masked()
+		}
+		if raw.ASN != nil {
+			filter.ASN, filter.HasASN = *raw.ASN, true
+		}
+		if !filter.Prefix.IsValid() && !filter.HasASN {
+			return nil, fmt.Errorf("%s: prefix filter %d has neither prefix nor asn", path, i)
+		}
+		slurm.Filters = append(slurm.Filters, filter)
+	}
+	for i, raw := range file.LocallyAddedAssertions.PrefixAssertions {
+		if raw.ASN == nil {
+			return nil, fmt.Errorf("%s: prefix assertion %d has no asn", path, i)
+		}
+		maxLength := 0
+		if raw.MaxPrefixLength != nil {
+			maxLength = *raw.MaxPrefixLength
+		}
+		vrp, err := newVRP(raw.Prefix, maxLength, *raw.ASN, "slurm")
+		if err != nil {
+			return nil, fmt.Errorf("%s: prefix assertion %d: %v", path, i, err)
+		}
+		slurm.Assertions = append(slurm.Assertions, vrp)
+	}
+	return slurm, nil
+}
+
+// Apply returns the VRPs with the filters and assertions applied.
+func (s *SLURM) Apply(vrps []VRP) []VRP {
+	var result []VRP
+	for _, vrp := range vrps {
+		filtered := false
+		for _, filter := range s.Filters {
+			if filter.Matches(vrp) {
+				filtered = true
+				break
+			}
+		}
+		if !filtered {
+			result = append(result, vrp)
+		}
+	}
+	return append(result, s.Assertions...)
+}
+
+// MarshalJSON encodes the SLURM file in the RFC 8416 layout.
+func (s *SLURM) MarshalJSON() ([]byte, error) {
+	file := slurmFile{SlurmVersion: 1}
+	file.ValidationOutputFilters.PrefixFilters = []slurmPrefix{}
+	file.ValidationOutputFilters.BgpsecFilters = []any{}
+	file.LocallyAddedAssertions.PrefixAssertions = []slurmPrefix{}
+	file.LocallyAddedAssertions.BgpsecAssertions = []any{}
+
+	for _, filter := range s.Filters {
+		entry := slurmPrefix{Comment: filter.Comment}
+		if filter.Prefix.IsValid() {
+			entry.Prefix = filter.Prefix.String()
+		}
+		if filter.HasASN {
+			asn := filter.ASN
+			entry.ASN = &asn
+		}
+		file.ValidationOutputFilters.PrefixFilters = append(file.ValidationOutputFilters.PrefixFilters, entry)
+	}
+	for _, vrp := range s.Assertions {
+		asn, maxLength := vrp.ASN, vrp.MaxLength
+		file.LocallyAddedAssertions.PrefixAssertions = append(file.LocallyAddedAssertions.PrefixAssertions, slurmPrefix{
+			ASN:             &asn,
+			Prefix:          vrp.Prefix.String(),
+			MaxPrefixLength: &maxLength,
+			Comment:         vrp.TA,
+		})
+	}
+	return json.Marshal(file)
+}
+
+// ParseVRP parses a ROA written as "192.0.2.0/24-24 AS65000",
+// "192.0.2.0/24 AS65000" (max length equal to the prefix length) or
+// "192.0.2.0/24 24 AS65000".
+func ParseVRP(text string) (VRP, error) {
+	var rawPrefix string
+	maxLength := 0
+	var asn uint32
+	hasASN := false
+
+	for _, field := range strings.Fields(strings.ReplaceAll(text, ",", " ")) {
+		switch {
+		case strings.Contains(field, "/"):
+			prefix, length, found := strings.Cut(field, "-")
+			rawPrefix = prefix
+			if found {
+				n, err := strconv.Atoi(length)
+				if err != nil {
+					return VRP{}, fmt.Errorf("invalid max length in %q", field)
+				}
+				maxLength = n
+			}
+		case strings.HasPrefix(strings.ToUpper(field), "AS"):
+			n, err := ParseASN(field)
+			if err != nil {
+				return VRP{}, err
+			}
+			asn, hasASN = n, true
+		default:
+			n, err := strconv.Atoi(field)
+			if err != nil {
+				return VRP{}, fmt.Errorf("unexpected %q in ROA %q", field, text)
+			}
+			maxLength = n
+		}
+	}
+
+	if rawPrefix == "" || !hasASN {
+		return VRP{}, fmt.Errorf("invalid ROA %q: expected \"prefix[-maxLength] ASn\"", text)
+	}
+	return newVRP(rawPrefix, maxLength, asn, "")
+}
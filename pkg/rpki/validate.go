@@ -0,0 +1,179 @@
+package rpki
+
+import (
+	"net/netip"
+	"sort"
+	"strings"
+
+	"github.com/drksbr/lg2/pkg/parser"
+)
+
+// State is an RFC 6811 route origin validation state.
+type State int
+
+const (
+	StateUnknown  State = iota // Não avaliado
+	StateValid                 // Valid
+	StateInvalid               // Invalid
+	StateNotFound              // NotFound
+)
+
+// String returns the lower-case name of the state.
+func (s State) String() string {
+	switch s {
+	case StateValid:
+		return "valid"
+	case StateInvalid:
+		return "invalid"
+	case StateNotFound:
+		return "not-found"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseState normalises the validation state reported by the looking glass.
+func ParseState(text string) State {
+	text = strings.ToLower(text)
+	switch {
+	case strings.Contains(text, "invalid"):
+		return StateInvalid
+	case strings.Contains(text, "not found"), strings.Contains(text, "not-found"),
+		strings.Contains(text, "notfound"), strings.Contains(text, "unknown"):
+		return StateNotFound
+	case strings.Contains(text, "valid"):
+		return StateValid
+	default:
+		return StateUnknown
+	}
+}
+
+// Validation is the outcome of validating one route.
+type Validation struct {
+	State    State
+	Covering []VRP // VRPs que cobrem o prefixo
+}
+
+// Validator validates route origins. Implementations must be safe for
+// concurrent use.
+type Validator interface {
+	Validate(prefix netip.Prefix, origin uint32, hasOrigin bool) Validation
+}
+
+// Feed is a Validator whose table changes while the program runs. Updates
+// receives a value after every change, so the routes can be re-validated.
+type Feed interface {
+	Validator
+	Updates() <-chan struct{}
+}
+
+// VRPSet is an immutable, indexed set of VRPs.
+type VRPSet struct {
+	byPrefix map[netip.Prefix][]VRP
+	count    int
+}
+
+// NewVRPSet indexes the VRPs by prefix. Duplicates are dropped.
+func NewVRPSet(vrps []VRP) *VRPSet {
+	set := &VRPSet{byPrefix: make(map[netip.Prefix][]VRP)}
+	for _, vrp := range vrps {
+		vrp.Prefix = vrp.Prefix.Masked()
+		duplicate := false
+		for _, existing := range set.byPrefix[vrp.Prefix] {
+			if existing.ASN == vrp.ASN && existing.MaxLength == vrp.MaxLength {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			set.byPrefix[vrp.Prefix] = append(set.byPrefix[vrp.Prefix], vrp)
+			set.count++
+		}
+	}
+	return set
+}
+
+// Len returns the number of VRPs in the set.
+func (s *VRPSet) Len() int {
+	if s == nil {
+		return 0
+	}
+	return s.count
+}
+
+// All returns every VRP in a stable order.
+func (s *VRPSet) All() []VRP {
+	if s == nil {
+		return nil
+	}
+	vrps := make([]VRP, 0, s.count)
+	for _, group := range s.byPrefix {
+		vrps = append(vrps, group...)
+	}
+	SortVRPs(vrps)
+	return vrps
+}
+
+// SortVRPs orders VRPs by prefix, max length and ASN.
+func SortVRPs(vrps []VRP) {
+	sort.Slice(vrps, func(i, j int) bool {
+		a, b := vrps[i], vrps[j]
+		if c := a.Prefix.Addr().Compare(b.Prefix.Addr()); c != 0 {
+			return c < 0
+		}
+		if a.Prefix.Bits() != b.Prefix.Bits() {
+			return a.Prefix.Bits() < b.Prefix.Bits()
+		}
+		if a.MaxLength != b.MaxLength {
+			return a.MaxLength < b.MaxLength
+		}
+		return a.ASN < b.ASN
+	})
+}
+
+// Covering returns the VRPs whose prefix covers the given prefix.
+func (s *VRPSet) Covering(prefix netip.Prefix) []VRP {
+	if s == nil || !prefix.IsValid() {
+		return nil
+	}
+	var covering []VRP
+	for bits := prefix.Bits(); bits >= 0; bits-- {
+		parent := netip.PrefixFrom(prefix.Addr(), bits).Masked()
+		covering = append(covering, s.byPrefix[parent]...)
+	}
+	return covering
+}
+
+// Validate implements Validator following RFC 6811: a route is valid when a
+// covering VRP matches its origin and length, invalid when it is covered but
+// no VRP matches, and not found when no VRP covers it. AS0 VRPs never match.
+func (s *VRPSet) Validate(prefix netip.Prefix, origin uint32, hasOrigin bool) Validation {
+	covering := s.Covering(prefix)
+	if len(covering) == 0 {
+		return Validation{State: StateNotFound}
+	}
+	for _, vrp := range covering {
+		if hasOrigin && vrp.ASN != 0 && vrp.ASN == origin && prefix.Bits() <= vrp.MaxLength {
+			return Validation{State: StateValid, Covering: covering}
+		}
+	}
+	return Validation{State: StateInvalid, Covering: covering}
+}
+
+// ValidatePeer validates the route prefix the peer returned, which may be
+// a covering route when an address was queried. A nil validator, or a peer
+// whose route prefix is unknown, yields StateUnknown.
+func ValidatePeer(v Validator, peer *parser.Peer) Validation {
+	if v == nil || !peer.Prefix.IsValid() {
+		return Validation{State: StateUnknown}
+	}
+	origin, ok := peer.OriginAS()
+	return v.Validate(peer.Prefix, uint32(origin), ok)
+}
+
+// Disagrees reports whether the local verdict contradicts the one reported
+// by the looking glass. Unknown states on either side never disagree.
+func Disagrees(local State, lgText string) bool {
+	lg := ParseState(lgText)
+	return local != StateUnknown && lg != StateUnknown && local != lg
+}
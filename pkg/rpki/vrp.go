@@ -0,0 +1,166 @@
+// Package rpki implements local RPKI route origin validation (RFC 6811)
+// against Validated ROA Payloads exported by rpki-client or Routinator.
+package rpki
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// VRP is a Validated ROA Payload.
+type VRP struct {
+	Prefix    netip.Prefix // Prefixo autorizado
+	MaxLength int          // Tamanho máximo de prefixo autorizado
+	ASN       uint32       // ASN autorizado a originar
+	TA        string       // Trust anchor (se conhecida)
+}
+
+// String renders the VRP as "prefix-maxLength ASn".
+func (v VRP) String() string {
+	return fmt.Sprintf("%s-%d AS%d", v.Prefix, v.MaxLength, v.ASN)
+}
+
+// Covers reports whether the VRP prefix covers the given prefix.
+func (v VRP) Covers(prefix netip.Prefix) bool {
+	return v.Prefix.Bits() <= prefix.Bits() && v.Prefix.Contains(prefix.Addr())
+}
+
+// LoadVRPFile reads a VRP export. JSON exports (rpki-client, Routinator
+// "json"/"jsonext") are detected by content; anything else is read as CSV
+// ("ASN,IP Prefix,Max Length,Trust Anchor").
+func LoadVRPFile(path string) (*VRPSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read VRP file: %v", err)
+	}
+
+	var vrps []VRP
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		vrps, err = ParseVRPJSON(trimmed)
+	} else {
+		vrps, err = ParseVRPCSV(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return NewVRPSet(vrps), nil
+}
+
+// ParseVRPJSON parses the "roas" array of an rpki-client or Routinator
+// JSON export. The ASN may be a number or an "AS65000" string.
+func ParseVRPJSON(data []byte) ([]VRP, error) {
+	var export struct {
+		ROAs []struct {
+			ASN       jsonASN `json:"asn"`
+			Prefix    string  `json:"prefix"`
+			MaxLength int     `json:"maxLength"`
+			TA        string  `json:"ta"`
+		} `json:"roas"`
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("invalid VRP JSON: %v", err)
+	}
+
+	vrps := make([]VRP, 0, len(export.ROAs))
+	for i, roa := range export.ROAs {
+		vrp, err := newVRP(roa.Prefix, roa.MaxLength, uint32(roa.ASN), roa.TA)
+		if err != nil {
+			return nil, fmt.Errorf("roa %d: %v", i, err)
+		}
+		vrps = append(vrps, vrp)
+	}
+	return vrps, nil
+}
+
+// ParseVRPCSV parses a CSV export with the columns ASN, prefix, max length
+// and optionally trust anchor. A header line is skipped.
+func ParseVRPCSV(r io.Reader) ([]VRP, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	var vrps []VRP
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid VRP CSV: %v", err)
+		}
+		if len(record) < 3 {
+			return nil, fmt.Errorf("line %d: expected ASN,prefix,maxLength", line)
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "ASN") {
+			continue
+		}
+
+		asn, err := ParseASN(record[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		maxLength, err := strconv.Atoi(strings.TrimSpace(record[2]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid max length %q", line, record[2])
+		}
+		ta := ""
+		if len(record) > 3 {
+			ta = strings.TrimSpace(record[3])
+		}
+		vrp, err := newVRP(record[1], maxLength, asn, ta)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		vrps = append(vrps, vrp)
+	}
+	return vrps, nil
+}
+
+// newVRP validates and builds a VRP. A missing max length defaults to the
+// prefix length.
+func newVRP(rawPrefix string, maxLength int, asn uint32, ta string) (VRP, error) {
+	prefix, err := netip.ParsePrefix(strings.TrimSpace(rawPrefix))
+	if err != nil {
+		return VRP{}, fmt.Errorf("invalid prefix %q", rawPrefix)
+	}
+	prefix = prefix.Masked()
+	if maxLength == 0 {
+		maxLength = prefix.Bits()
+	}
+	if maxLength < prefix.Bits() || maxLength > prefix.Addr().BitLen() {
+		return VRP{}, fmt.Errorf("invalid max length %d for %s", maxLength, prefix)
+	}
+	return VRP{Prefix: prefix, MaxLength: maxLength, ASN: asn, TA: ta}, nil
+}
+
+// ParseASN parses "65000", "AS65000" or "as65000".
+func ParseASN(text string) (uint32, error) {
+	text = strings.TrimSpace(text)
+	raw := strings.TrimPrefix(strings.ToUpper(text), "AS")
+	asn, err := strconv.ParseUint(raw, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ASN %q", text)
+	}
+	return uint32(asn), nil
+}
+
+// jsonASN accepts an ASN encoded as a number or as an "AS65000" string.
+type jsonASN uint32
+
+func (a *jsonASN) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	asn, err := ParseASN(text)
+	if err != nil {
+		return err
+	}
+	*a = jsonASN(asn)
+	return nil
+}
@@ -0,0 +1,85 @@
+package rpki
+
+import "github.com/drksbr/lg2/pkg/parser"
+
+// RouteChange is the validation of one peer's route before and after a
+// candidate VRP set is applied.
+type RouteChange struct {
+	Peer   *parser.Peer
+	Origin int // ASN de origem (0 se não há origem)
+	Before State
+	After  State
+}
+
+// Flipped reports whether the validation state changes.
+func (c RouteChange) Flipped() bool {
+	return c.Before != c.After
+}
+
+// SimulationSummary counts the outcomes of a simulation.
+type SimulationSummary struct {
+	Routes     int
+	Unchanged  int
+	ToInvalid  int // Rotas que passam a ser inválidas
+	ToValid    int // Rotas que passam a ser válidas
+	ToNotFound int // Rotas que deixam de ser cobertas
+}
+
+// Simulate validates every peer's route against the current and the
+// candidate VRP sets.
+func Simulate(current, candidate Validator, peers []parser.Peer) ([]RouteChange, SimulationSummary) {
+	changes := make([]RouteChange, 0, len(peers))
+	var summary SimulationSummary
+
+	for i := range peers {
+		peer := &peers[i]
+		origin, _ := peer.OriginAS()
+		change := RouteChange{
+			Peer:   peer,
+			Origin: origin,
+			Before: ValidatePeer(current, peer).State,
+			After:  ValidatePeer(candidate, peer).State,
+		}
+		changes = append(changes, change)
+
+		summary.Routes++
+		if !change.Flipped() {
+			summary.Unchanged++
+			continue
+		}
+		switch change.After {
+		case StateInvalid:
+			summary.ToInvalid++
+		case StateValid:
+			summary.ToValid++
+		case StateNotFound:
+			summary.ToNotFound++
+		}
+	}
+
+	return changes, summary
+}
+
+// Candidate builds a candidate VRP set from the current VRPs, removing the
+// VRPs that match remove (same prefix and ASN), adding add and applying the
+// optional SLURM file last.
+func Candidate(current *VRPSet, add, remove []VRP, slurm *SLURM) *VRPSet {
+	var vrps []VRP
+	for _, vrp := range current.All() {
+		removed := false
+		for _, r := range remove {
+			if r.Prefix == vrp.Prefix && r.ASN == vrp.ASN {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			vrps = append(vrps, vrp)
+		}
+	}
+	vrps = append(vrps, add...)
+	if slurm != nil {
+		vrps = slurm.Apply(vrps)
+	}
+	return NewVRPSet(vrps)
+}
@@ -0,0 +1,241 @@
+package traceroute
+
+import (
+	"net/netip"
+	"regexp"
+	"slices"
+
+	"github.com/drksbr/lg2/pkg/analysis"
+	"github.com/drksbr/lg2/pkg/bogon"
+	"github.com/drksbr/lg2/pkg/ip2asn"
+	"github.com/drksbr/lg2/pkg/parser"
+)
+
+// HopKind classifies a hop after mapping its address to an AS.
+type HopKind string
+
+const (
+	HopAS          HopKind = "as"          // Endereço mapeado para um ASN
+	HopPrivate     HopKind = "private"     // Endereço privado ou reservado
+	HopIXP         HopKind = "ixp"         // LAN de IXP (nome ou endereço não anunciado entre dois ASes)
+	HopUnannounced HopKind = "unannounced" // Endereço público fora da tabela
+	HopNoReply     HopKind = "no-reply"
+)
+
+// ixpName matches reverse names of IXP peering LANs ("ae0.ams-ix.net",
+// "as64500.ix.br", "decix-fra.example.net").
+var ixpName = regexp.MustCompile(`(?i)(^|[.-])(ixp?|ix\.br|ams-?ix|de-?cix|linx|lonap|nl-?ix|franceix|equinix|megaport|ptt|exchange)([.-]|\d|$)`)
+
+// MappedHop is a hop with the AS its address belongs to.
+type MappedHop struct {
+	Hop
+	Kind   HopKind
+	Prefix netip.Prefix // Prefixo da tabela que contém o endereço
+}
+
+// MapHops maps every hop to an ASN with the prefix table, falling back to
+// the ASN mtr reported. Private and reserved addresses are recognised with
+// the bogon list. A public address missing from the table between two
+// mapped hops, or whose name looks like an IXP, is taken as an IXP LAN.
+func MapHops(trace *Trace, table *ip2asn.Table, bogons *bogon.List) []MappedHop {
+	hops := make([]MappedHop, len(trace.Hops))
+	for i, hop := range trace.Hops {
+		mapped := MappedHop{Hop: hop}
+		switch {
+		case !hop.Addr.IsValid() && hop.Host == "":
+			mapped.Kind = HopNoReply
+		case hop.Addr.IsValid() && isReserved(hop.Addr, bogons):
+			mapped.Kind = HopPrivate
+		case ixpName.MatchString(hop.Host):
+			mapped.Kind = HopIXP
+		default:
+			if entry, ok := table.Lookup(hop.Addr); ok && len(entry.Origins) > 0 {
+				mapped.ASN, mapped.HasASN = entry.Origins[0], true
+				mapped.Prefix = entry.Prefix
+			}
+			mapped.Kind = HopUnannounced
+			if mapped.HasASN { // Da tabela ou do próprio mtr
+				mapped.Kind = HopAS
+			}
+		}
+		hops[i] = mapped
+	}
+
+	// Unannounced addresses between two ASes are most likely an IXP LAN
+	for i := range hops {
+		if hops[i].Kind != HopUnannounced || !hops[i].Addr.IsValid() {
+			continue
+		}
+		before := slices.ContainsFunc(hops[:i], func(h MappedHop) bool { return h.Kind == HopAS })
+		after := slices.ContainsFunc(hops[i+1:], func(h MappedHop) bool { return h.Kind == HopAS })
+		if before && after {
+			hops[i].Kind = HopIXP
+		}
+	}
+	return hops
+}
+
+// isReserved reports whether the address is private or otherwise reserved.
+func isReserved(addr netip.Addr, bogons *bogon.List) bool {
+	addr = addr.Unmap()
+	if addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() {
+		return true
+	}
+	_, ok := bogons.Prefix(netip.PrefixFrom(addr, addr.BitLen()))
+	return ok
+}
+
+// DataPlanePath returns the ASes the traceroute crosses, in order, with
+// consecutive repeats removed.
+func DataPlanePath(hops []MappedHop) []int {
+	var path []int
+	for _, hop := range hops {
+		if hop.Kind != HopAS {
+			continue
+		}
+		if asn := int(hop.ASN); len(path) == 0 || path[len(path)-1] != asn {
+			path = append(path, asn)
+		}
+	}
+	return path
+}
+
+// Correlation compares the data-plane AS sequence of a traceroute with the
+// control-plane AS paths of the looking glass peers.
+type Correlation struct {
+	Hops      []MappedHop
+	DataPlane []int
+
+	// Peer whose AS path best matches the traceroute. Both paths are only
+	// compared from the first AS they share (Common) to the origin: the
+	// ASes before it are the traceroute source's and the peer's own.
+	Peer         string
+	ControlPlane []int
+	Common       int
+	HasCommon    bool
+	Diff         analysis.PathDiff
+
+	Hidden []int       // ASes do caminho BGP que não aparecem no traceroute
+	Extra  []int       // ASes do traceroute ausentes de todos os caminhos BGP
+	IXPs   []MappedHop // Saltos em LANs de IXP
+
+	// The traceroute enters the origin through an AS no peer uses as the
+	// upstream of the origin, or ends at another AS
+	Asymmetric bool
+	Entry      int   // AS pelo qual o traceroute chega à origem
+	Upstreams  []int // Upstreams da origem vistos pelos peers
+	Origin     int
+	HasOrigin  bool
+}
+
+// Correlate maps the traceroute hops and compares the result with the AS
+// paths of the peers. The closest peer is the one sharing the most ASes in
+// order with the traceroute, from their first common AS on, preferring
+// shorter diffs.
+func Correlate(trace *Trace, peers []parser.Peer, table *ip2asn.Table, bogons *bogon.List) Correlation {
+	c := Correlation{Hops: MapHops(trace, table, bogons)}
+	c.DataPlane = DataPlanePath(c.Hops)
+	for _, hop := range c.Hops {
+		if hop.Kind == HopIXP {
+			c.IXPs = append(c.IXPs, hop)
+		}
+	}
+
+	best, start := -1, 0 // start: primeiro AS do traceroute comparado
+	for i := range peers {
+		path, _ := analysis.CollapsedPath(&peers[i])
+		if len(path) == 0 {
+			continue
+		}
+		score, from, diff := -1, len(c.DataPlane), analysis.PathDiff(nil)
+		if dp, cp, ok := firstCommon(c.DataPlane, path); ok {
+			diff = analysis.DiffPath(c.DataPlane[dp:], path[cp:])
+			score, from = sameCount(diff), dp
+		}
+		if c.Peer == "" || score > best || (score == best && len(diff) < len(c.Diff)) {
+			best, start = score, from
+			c.Peer, c.ControlPlane, c.Diff = peers[i].PeerName, path, diff
+			c.HasCommon = score >= 0
+			if c.HasCommon {
+				c.Common = c.DataPlane[from]
+			}
+		}
+	}
+	if c.Peer == "" {
+		return c
+	}
+
+	// Hidden ASes are those of the closest path; extra ones are absent from
+	// every path. Neither is looked for before the first common AS.
+	for _, op := range c.Diff {
+		if op.Op == analysis.DiffMissing {
+			c.Hidden = append(c.Hidden, op.ASN)
+		}
+	}
+	known := map[int]bool{}
+	for i := range peers {
+		path, _ := analysis.CollapsedPath(&peers[i])
+		for _, asn := range path {
+			known[asn] = true
+		}
+	}
+	for _, asn := range c.DataPlane[start:] {
+		if !known[asn] {
+			c.Extra = append(c.Extra, asn)
+		}
+	}
+
+	// Compare the way into the origin with the upstreams the peers see
+	for _, origin := range analysis.Origins(peers) {
+		if origin.HasOrigin {
+			c.Origin, c.HasOrigin = origin.Origin, true
+			break
+		}
+	}
+	for _, share := range analysis.DistributeUpstreams(peers, 1).Upstreams {
+		c.Upstreams = append(c.Upstreams, share.ASN)
+	}
+	if n := len(c.DataPlane); c.HasOrigin && n > 0 {
+		switch {
+		case c.DataPlane[n-1] != c.Origin:
+			c.Asymmetric = trace.reachedTarget(c.Hops)
+		case n > 1:
+			c.Entry = c.DataPlane[n-2]
+			c.Asymmetric = !slices.Contains(c.Upstreams, c.Entry)
+		}
+	}
+	return c
+}
+
+// reachedTarget reports whether the last hop that answered is the
+// destination, so an unexpected last AS is not just an incomplete trace.
+func (t *Trace) reachedTarget(hops []MappedHop) bool {
+	for i := len(hops) - 1; i >= 0; i-- {
+		if hops[i].Addr.IsValid() {
+			return t.Target.IsValid() && hops[i].Addr == t.Target
+		}
+	}
+	return false
+}
+
+// firstCommon returns the position in both paths of the first AS of the
+// traceroute that the control-plane path also crosses.
+func firstCommon(dataPlane, path []int) (int, int, bool) {
+	for i, asn := range dataPlane {
+		if j := slices.Index(path, asn); j >= 0 {
+			return i, j, true
+		}
+	}
+	return 0, 0, false
+}
+
+// sameCount returns how many ASes the diff keeps.
+func sameCount(diff analysis.PathDiff) int {
+	n := 0
+	for _, op := range diff {
+		if op.Op == analysis.DiffSame {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,172 @@
+// Package traceroute reads traceroutes and correlates the ASes they cross
+// with the AS paths the looking glass peers report.
+package traceroute
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/netip"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Hop is one TTL of a traceroute. Only the first address that answered is
+// kept; Addr is invalid when nothing answered.
+type Hop struct {
+	TTL    int
+	Addr   netip.Addr
+	Host   string // Nome reverso, quando o traceroute resolveu
+	ASN    uint32 // ASN informado pelo próprio mtr (-z), se houver
+	HasASN bool
+}
+
+// Trace is a parsed traceroute, in TTL order.
+type Trace struct {
+	Destination string // Como informado no cabeçalho (nome ou endereço)
+	Target      netip.Addr
+	Hops        []Hop
+}
+
+// Parse reads a traceroute in `mtr --json`, traceroute or tracepath
+// format, detected from the content.
+func Parse(r io.Reader) (*Trace, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimSpace(data); bytes.HasPrefix(trimmed, []byte("{")) {
+		return ParseMTR(trimmed)
+	}
+	return ParseText(bytes.NewReader(data))
+}
+
+// mtrReport is the part of `mtr --json` output lg uses.
+type mtrReport struct {
+	Report struct {
+		MTR struct {
+			Dst string `json:"dst"`
+		} `json:"mtr"`
+		Hubs []struct {
+			Count json.Number `json:"count"`
+			Host  string      `json:"host"`
+			ASN   string      `json:"ASN"`
+		} `json:"hubs"`
+	} `json:"report"`
+}
+
+// ParseMTR reads the JSON report of `mtr --json`. With -z mtr includes the
+// ASN of every hop, used when the prefix table has no answer.
+func ParseMTR(data []byte) (*Trace, error) {
+	var report mtrReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("invalid mtr JSON: %v", err)
+	}
+
+	trace := &Trace{Destination: report.Report.MTR.Dst}
+	trace.Target, _ = netip.ParseAddr(trace.Destination)
+	for i, hub := range report.Report.Hubs {
+		hop := Hop{TTL: i + 1}
+		if ttl, err := hub.Count.Int64(); err == nil && ttl > 0 {
+			hop.TTL = int(ttl)
+		}
+		if hub.Host != "???" {
+			hop.Addr, hop.Host = hostAddr(strings.Fields(hub.Host))
+		}
+		if asn, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(hub.ASN), "AS"), 10, 32); err == nil && asn > 0 {
+			hop.ASN, hop.HasASN = uint32(asn), true
+		}
+		trace.Hops = append(trace.Hops, hop)
+	}
+	if len(trace.Hops) == 0 {
+		return nil, fmt.Errorf("mtr report without hops")
+	}
+	return trace, nil
+}
+
+var (
+	// "traceroute to example.com (93.184.216.34), 30 hops max"
+	tracerouteHeader = regexp.MustCompile(`^traceroute6? to (\S+)(?: \(([^)]+)\))?`)
+	// " 3  host (192.0.2.1)  1.2 ms" e " 1?: [LOCALHOST]  pmtu 1500"
+	hopLine = regexp.MustCompile(`^\s*(\d+)\??:?\s+(.*)$`)
+)
+
+// ParseText reads the output of traceroute or tracepath, with or without
+// name resolution. tracepath repeats a TTL while probing the path MTU; the
+// first line with an address wins.
+func ParseText(r io.Reader) (*Trace, error) {
+	trace := &Trace{}
+	byTTL := map[int]int{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		if match := tracerouteHeader.FindStringSubmatch(line); match != nil {
+			trace.Destination = match[1]
+			trace.Target, _ = netip.ParseAddr(match[2])
+			if !trace.Target.IsValid() {
+				trace.Target, _ = netip.ParseAddr(match[1])
+			}
+			continue
+		}
+		match := hopLine.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		ttl, _ := strconv.Atoi(match[1])
+		fields := strings.Fields(match[2])
+		if len(fields) > 0 && fields[0] == "[LOCALHOST]" {
+			continue
+		}
+
+		hop := Hop{TTL: ttl}
+		hop.Addr, hop.Host = hostAddr(fields)
+		if slices.Contains(fields, "reached") && !trace.Target.IsValid() {
+			trace.Target = hop.Addr // tracepath não tem cabeçalho com o destino
+		}
+		if index, seen := byTTL[ttl]; seen {
+			if !trace.Hops[index].Addr.IsValid() && hop.Addr.IsValid() {
+				trace.Hops[index] = hop
+			}
+			continue
+		}
+		byTTL[ttl] = len(trace.Hops)
+		trace.Hops = append(trace.Hops, hop)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(trace.Hops) == 0 {
+		return nil, fmt.Errorf("no traceroute, tracepath or mtr hops found")
+	}
+	return trace, nil
+}
+
+// hostAddr finds the first responding host in the fields of a hop line:
+// "name (address)", a bare address, or a name tracepath could not map.
+func hostAddr(fields []string) (netip.Addr, string) {
+	for i, field := range fields {
+		if field == "*" || field == "no" { // "*" do traceroute, "no reply" do tracepath
+			continue
+		}
+		if addr, err := netip.ParseAddr(field); err == nil {
+			return addr, ""
+		}
+		if i+1 < len(fields) && strings.HasPrefix(fields[i+1], "(") {
+			if addr, err := netip.ParseAddr(strings.Trim(fields[i+1], "()")); err == nil {
+				return addr, field
+			}
+		}
+		if strings.HasSuffix(field, "ms") || field == "reply" || strings.HasPrefix(field, "!") {
+			continue
+		}
+		if _, err := strconv.ParseFloat(field, 64); err == nil {
+			continue
+		}
+		return netip.Addr{}, field
+	}
+	return netip.Addr{}, ""
+}
@@ -0,0 +1,246 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/drksbr/lg2/pkg/analysis"
+	"github.com/drksbr/lg2/pkg/parser"
+	"github.com/drksbr/lg2/pkg/report"
+	"github.com/rivo/tview"
+)
+
+// buildAnalysisView resume o que todos os peers do conjunto atual veem.
+func (tui *TUI) buildAnalysisView() string {
+	if len(tui.originalPeers) == 0 {
+		return "[::b]Analysis:[::-] no peers to analyse.\n\nPress ['a'] to go back."
+	}
+
+	var view strings.Builder
+	view.WriteString(fmt.Sprintf("[::b]Analysis:[::-] %s, %d peers\n\n", tui.query, len(tui.originalPeers)))
+	view.WriteString(formatCommunityBanner(tui.originalPeers, tui.opts.Communities))
+	view.WriteString(fmt.Sprintf("[::b]Origins:[::-] %s\n\n", formatOrigins(tui.query.RoutePrefix(tui.originalPeers), analysis.Origins(tui.originalPeers), tui.opts)))
+	view.WriteString(formatPolicyCompliance(tui.query, tui.originalPeers, tui.opts))
+	view.WriteString(formatConsensus(tui.consensus))
+	view.WriteString(formatLengthStats(analysis.PathLengthStats(tui.originalPeers)))
+	view.WriteString(formatUpstreamDistribution("Upstreams (adjacent to the origin)", analysis.DistributeUpstreams(tui.originalPeers, 1)))
+	view.WriteString(formatUpstreamDistribution("Transit (next hop up)", analysis.DistributeUpstreams(tui.originalPeers, 2)))
+	view.WriteString(formatPrependingSummary(analysis.SummarizePrepending(tui.originalPeers)))
+	view.WriteString(formatGeoSummary(analysis.SummarizeGeo(tui.originalPeers, tui.opts.LocalRegion)))
+	view.WriteString(formatAnycast(analysis.DetectAnycast(tui.originalPeers)))
+	return view.String()
+}
+
+// formatPolicyCompliance mostra quantos peers seguem os upstreams e as
+// comunidades declarados para o prefixo, e quais não seguem.
+func formatPolicyCompliance(query parser.Query, peers []parser.Peer, opts Options) string {
+	rule, ok := opts.Policy.Lookup(query.RoutePrefix(peers))
+	if !ok || (len(rule.Upstreams) == 0 && len(rule.Communities) == 0) {
+		return ""
+	}
+
+	var declared []string
+	if len(rule.Upstreams) > 0 {
+		upstreams := make([]string, len(rule.Upstreams))
+		for i, asn := range rule.Upstreams {
+			upstreams[i] = fmt.Sprintf("AS%d", asn)
+		}
+		declared = append(declared, "via "+strings.Join(upstreams, " or "))
+	}
+	if len(rule.Communities) > 0 {
+		declared = append(declared, "tagged "+strings.Join(rule.Communities, ", "))
+	}
+
+	var text, violators strings.Builder
+	compliant, unevaluated := 0, 0
+	for i := range peers {
+		violations := report.PolicyViolations(query, &peers[i], opts)
+		broken := false
+		for _, v := range violations {
+			mark := "[red]✗[-]"
+			if v.Unevaluated() {
+				mark = "[yellow]?[-]"
+			} else {
+				broken = true
+			}
+			violators.WriteString(fmt.Sprintf("     %s %s: %s\n", mark, tview.Escape(peers[i].PeerName), tview.Escape(v.Message)))
+		}
+		if len(violations) == 0 {
+			compliant++
+		} else if !broken {
+			unevaluated++
+		}
+	}
+	color := "green"
+	if compliant+unevaluated < len(peers) {
+		color = "red"
+	}
+	text.WriteString(fmt.Sprintf("[::b]Policy %s:[::-] %s, [%s]%d of %d peers comply[-]", rule.Prefix, strings.Join(declared, ", "), color, compliant, len(peers)))
+	if unevaluated > 0 {
+		text.WriteString(fmt.Sprintf(", [yellow]%d cannot be evaluated[-]", unevaluated))
+	}
+	text.WriteString("\n")
+	text.WriteString(violators.String())
+	text.WriteString("\n")
+	return text.String()
+}
+
+// formatConsensus mostra o caminho mais comum, o sufixo que a maioria
+// compartilha e os peers que fogem dele.
+func formatConsensus(consensus analysis.Consensus) string {
+	if consensus.Peers == 0 {
+		return ""
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("[::b]Consensus path:[::-] %s (%d of %d peers)\n", joinASNs(consensus.Path), consensus.PathPeers, consensus.Peers))
+	if len(consensus.Suffix) > 0 {
+		text.WriteString(fmt.Sprintf("     common suffix: %s (%d of %d peers)\n", joinASNs(consensus.Suffix), consensus.SuffixPeers, consensus.Peers))
+	} else {
+		text.WriteString("     no suffix is shared by most peers\n")
+	}
+	for _, outlier := range consensus.Outliers {
+		text.WriteString(fmt.Sprintf("     [yellow]≠[-] %s  %s\n", tview.Escape(outlier.Peer), colorPathDiff(outlier.Diff)))
+	}
+	text.WriteString("\n")
+	return text.String()
+}
+
+// formatAnycast mostra a estimativa de anycast, a evidência por trás dela e
+// por onde cada região chega à origem.
+func formatAnycast(anycast analysis.Anycast) string {
+	if anycast.Peers == 0 {
+		return ""
+	}
+
+	var text strings.Builder
+	verdict := "not anycast"
+	switch anycast.Confidence {
+	case analysis.ConfidenceHigh, analysis.ConfidenceMedium:
+		verdict = fmt.Sprintf("[green::b]likely anycast[-::-], %s confidence", anycast.Confidence)
+	case analysis.ConfidenceLow:
+		verdict = "possibly anycast, low confidence"
+	}
+	text.WriteString(fmt.Sprintf("[::b]Anycast:[::-] %s (score %d of %d)\n", verdict, anycast.Score, anycast.MaxScore))
+	for _, signal := range anycast.Evidence {
+		mark := "[red]✗[-]"
+		if signal.Met {
+			mark = "[green]✓[-]"
+		}
+		text.WriteString(fmt.Sprintf("     %s %s: %s\n", mark, signal.Signal, signal.Detail))
+	}
+	if len(anycast.Entries) > 0 {
+		text.WriteString("     [::d]entry points by region:[::-]\n")
+	}
+	for _, entry := range anycast.Entries {
+		upstreams := make([]string, 0, 3)
+		for _, share := range entry.Upstreams[:min(3, len(entry.Upstreams))] {
+			upstreams = append(upstreams, fmt.Sprintf("AS%d %s (%d)", share.ASN, tview.Escape(share.Name), share.Peers))
+		}
+		text.WriteString(fmt.Sprintf("     %-14s %3d peers  median %.1f  %s\n", entry.Region, entry.Peers, entry.MedianLength, strings.Join(upstreams, ", ")))
+	}
+	text.WriteString("\n")
+	return text.String()
+}
+
+// joinASNs junta os ASNs de um caminho separados por espaço.
+func joinASNs(path []int) string {
+	parts := make([]string, len(path))
+	for i, asn := range path {
+		parts[i] = fmt.Sprintf("%d", asn)
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatPrependingSummary mostra, por upstream, quantos peers o escolheram e
+// quanto a origem prependa na direção dele.
+func formatPrependingSummary(upstreams []analysis.UpstreamPrepending) string {
+	if len(upstreams) == 0 {
+		return ""
+	}
+
+	var text strings.Builder
+	text.WriteString("[::b]Prepending by upstream:[::-]\n")
+	for _, upstream := range upstreams {
+		text.WriteString(fmt.Sprintf("     AS%-10d %3d peers  mean length %.1f", upstream.Upstream, upstream.Peers, upstream.MeanLength))
+		if upstream.Prepended > 0 {
+			text.WriteString(fmt.Sprintf("  [yellow]origin +%d[-] in %d peers", upstream.OriginPrepends, upstream.Prepended))
+		}
+		text.WriteString("\n")
+	}
+	text.WriteString("\n")
+	return text.String()
+}
+
+// formatUpstreamDistribution mostra a fatia de peers por AS e por país.
+func formatUpstreamDistribution(title string, dist analysis.UpstreamDistribution) string {
+	if dist.Peers == 0 {
+		return ""
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("[::b]%s:[::-] %d peers\n", title, dist.Peers))
+	for _, share := range dist.Upstreams {
+		text.WriteString(fmt.Sprintf("     %5.1f%% %3d  AS%-10d %s", share.Percent, share.Peers, share.ASN, tview.Escape(share.Name)))
+		if share.Country != "" {
+			text.WriteString(fmt.Sprintf(" |%s|", share.Country))
+		}
+		text.WriteString("\n")
+	}
+
+	countries := make([]string, len(dist.Countries))
+	for i, share := range dist.Countries {
+		countries[i] = fmt.Sprintf("%s %.0f%%", share.Country, share.Percent)
+	}
+	text.WriteString(fmt.Sprintf("     [::d]by country:[::-] %s\n\n", strings.Join(countries, ", ")))
+	return text.String()
+}
+
+// formatLengthStats mostra o histograma de tamanhos de caminho e os peers
+// fora da curva no próprio país.
+func formatLengthStats(stats analysis.LengthStats) string {
+	if stats.Peers == 0 {
+		return ""
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("[::b]Path length:[::-] mean %.1f / median %.1f\n", stats.Mean, stats.Median))
+	for _, bucket := range stats.Histogram {
+		bar := strings.Repeat("█", max(1, bucket.Peers*40/stats.Peers))
+		text.WriteString(fmt.Sprintf("     %3d %s %d\n", bucket.Length, bar, bucket.Peers))
+	}
+	text.WriteString(fmt.Sprintf("     shortest (%d): %s\n", stats.Shortest, tview.Escape(strings.Join(stats.Short, ", "))))
+	if stats.Longest != stats.Shortest {
+		text.WriteString(fmt.Sprintf("     longest (%d): %s\n", stats.Longest, tview.Escape(strings.Join(stats.Long, ", "))))
+	}
+	for _, outlier := range stats.Outliers {
+		text.WriteString(fmt.Sprintf("     [yellow]%s: %d, median in %s is %.1f[-]\n", tview.Escape(outlier.Peer), outlier.Length, outlier.Country, outlier.CountryMedian))
+	}
+	text.WriteString("\n")
+	return text.String()
+}
+
+// formatGeoSummary lista os peers cujos caminhos fazem trombone ou saem da
+// região local.
+func formatGeoSummary(summary analysis.GeoSummary) string {
+	if len(summary.Tromboning) == 0 && len(summary.LeavingRegion) == 0 {
+		return ""
+	}
+
+	var text strings.Builder
+	if len(summary.Tromboning) > 0 {
+		text.WriteString(fmt.Sprintf("[::b]Tromboning:[::-] %d of %d peers\n", len(summary.Tromboning), summary.Peers))
+		for _, peer := range summary.Tromboning {
+			text.WriteString(fmt.Sprintf("     %s  %s\n", tview.Escape(peer.Peer), peer.Path))
+		}
+		text.WriteString("\n")
+	}
+	if len(summary.LeavingRegion) > 0 {
+		text.WriteString(fmt.Sprintf("[::b]Leaving the local region:[::-] %d of %d peers\n", len(summary.LeavingRegion), summary.Peers))
+		for _, peer := range summary.LeavingRegion {
+			text.WriteString(fmt.Sprintf("     %s  %s\n", tview.Escape(peer.Peer), peer.Path))
+		}
+		text.WriteString("\n")
+	}
+	return text.String()
+}
@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/drksbr/lg2/pkg/analysis"
+	"github.com/drksbr/lg2/pkg/parser"
+)
+
+// contentView seleciona o que é exibido no quadro de detalhes.
+type contentView int
+
+const (
+	viewPeer contentView = iota
+	viewDualStack
+	viewAnalysis
+)
+
+// toggleView switches the content pane to the given view, or back to the
+// peer details when it is already shown.
+func (tui *TUI) toggleView(view contentView) {
+	if tui.view == view {
+		tui.view = viewPeer
+	} else {
+		tui.view = view
+	}
+	tui.updateContent()
+}
+
+// dualStackSets returns the IPv4 and IPv6 result sets to compare: the
+// current set and the first successful set of the other family.
+func (tui *TUI) dualStackSets() (v4, v6 *resultSet) {
+	if len(tui.resultSets) == 0 {
+		return nil, nil
+	}
+	current := &tui.resultSets[tui.currentSet]
+	currentIs4 := current.query.Is4()
+	for i := range tui.resultSets {
+		set := &tui.resultSets[i]
+		if set.err != nil || set.query.Is4() == currentIs4 {
+			continue
+		}
+		if currentIs4 {
+			return current, set
+		}
+		return set, current
+	}
+	return nil, nil
+}
+
+// buildDualStackView lines up the IPv4 and IPv6 AS paths of every peer.
+func (tui *TUI) buildDualStackView() string {
+	v4, v6 := tui.dualStackSets()
+	if v4 == nil || v6 == nil {
+		return "[::b]Dual-stack:[::-] query a hostname with both A and AAAA records to compare IPv4 and IPv6 paths.\n\nPress ['d'] to go back."
+	}
+
+	rows, summary := analysis.CompareDualStack(v4.peers, v6.peers)
+
+	var view strings.Builder
+	view.WriteString(fmt.Sprintf("[::b]Dual-stack:[::-] %s\n", v4.query.Host))
+	view.WriteString(fmt.Sprintf("     IPv4 %s (%s) / IPv6 %s (%s)\n", v4.query.Addr, v4.query.RoutePrefix(v4.peers), v6.query.Addr, v6.query.RoutePrefix(v6.peers)))
+	view.WriteString(fmt.Sprintf("     %d peers in both families, %d IPv4 only, %d IPv6 only\n", summary.Both, summary.OnlyV4, summary.OnlyV6))
+	view.WriteString(fmt.Sprintf("     [red]%d origin divergences[-], [yellow]%d upstream divergences[-]\n\n", summary.OriginDiverges, summary.UpstreamDiverges))
+
+	for _, row := range rows {
+		view.WriteString(fmt.Sprintf("[::b]%s[::-]", row.PeerName))
+		switch {
+		case row.OriginDiverges:
+			view.WriteString(" [red]origin differs[-]")
+		case row.UpstreamDiverges:
+			view.WriteString(" [yellow]upstream differs[-]")
+		}
+		view.WriteString("\n")
+		view.WriteString(fmt.Sprintf("     v4: %s\n", formatDualStackPath(row.V4)))
+		view.WriteString(fmt.Sprintf("     v6: %s\n", formatDualStackPath(row.V6)))
+	}
+
+	return view.String()
+}
+
+// formatDualStackPath formats one side of a dual-stack row.
+func formatDualStackPath(peer *parser.Peer) string {
+	if peer == nil {
+		return "[::d]no route[::-]"
+	}
+	return strings.ReplaceAll(formatASPath(peer.PathSegments()), "\n", "\n    ")
+}
@@ -2,33 +2,122 @@ package tui
 
 import (
 	"fmt"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/drksbr/lg2/pkg/community"
+	"github.com/drksbr/lg2/pkg/config"
+	"github.com/drksbr/lg2/pkg/irr"
 	"github.com/drksbr/lg2/pkg/parser"
+	"github.com/drksbr/lg2/pkg/policy"
+	"github.com/drksbr/lg2/pkg/report"
+	"github.com/drksbr/lg2/pkg/rpki"
+	"github.com/rivo/tview"
 )
 
+// peerListLabel monta o texto do peer na lista, destacando rotas alteradas recentemente.
+func (tui *TUI) peerListLabel(index int, peer *parser.Peer) string {
+	name := peer.PeerName
+	if group := tui.grouping.key(peer); group != "" {
+		name = fmt.Sprintf("[::d]%s[::-] %s", group, name)
+	}
+	if tui.opts.VRPs != nil {
+		local := rpki.ValidatePeer(tui.opts.VRPs, peer)
+		if rpki.Disagrees(local.State, peer.OriginValidation) {
+			name += " [red::b]![-::-]"
+		}
+	}
+	if tui.opts.IRR != nil && tui.opts.IRR.ValidatePeer(peer).State == irr.RouteInvalid {
+		name += " [red]irr[-]"
+	}
+	if len(tui.opts.IRR.CheckCones(peer)) > 0 || (tui.opts.Relationships != nil && len(tui.opts.Relationships.CheckPeer(peer).Leaks) > 0) {
+		name += " [red]leak[-]"
+	}
+	if origin, ok := peer.OriginAS(); tui.opts.Policy.UnexpectedOrigin(tui.query.RoutePrefix(tui.originalPeers), origin, ok) {
+		name += " [red::b]hijack?[-::-]"
+	}
+	if slices.ContainsFunc(report.PolicyViolations(tui.query, peer, tui.opts), func(v policy.Violation) bool { return !v.Unevaluated() }) {
+		name += " [red]policy[-]"
+	}
+	for _, kind := range alertKinds(tui.opts.Communities.Alerts(peer)) {
+		name += fmt.Sprintf(" [white:red:b]%s[-:-:-]", kind)
+	}
+	if !tui.consensus.Follows(peer) {
+		name += " [yellow]≠[-]"
+	}
+	if len(tui.opts.Bogons.CheckPath(peer)) > 0 {
+		name += " [red]bogon[-]"
+	}
+	if peer.RecentlyChanged(time.Now(), config.RecentWindow) {
+		return fmt.Sprintf("[%02d] [yellow::b]%s *[-::-]", index+1, name)
+	}
+	return fmt.Sprintf("[%02d] %s", index+1, name)
+}
+
+// alertKinds resume os alertas de comunidade de uma rota em rótulos curtos.
+func alertKinds(matches []community.Match) []string {
+	var kinds []string
+	for _, match := range matches {
+		label := "BH"
+		if match.Entry.Kind == community.KindGracefulShutdown {
+			label = "GSHUT"
+		}
+		if !slices.Contains(kinds, label) {
+			kinds = append(kinds, label)
+		}
+	}
+	return kinds
+}
+
+// peersListTitle monta o título da lista com a contagem de peers e de rotas recentes.
+func peersListTitle(peers []parser.Peer) string {
+	recent := 0
+	now := time.Now()
+	for i := range peers {
+		if peers[i].RecentlyChanged(now, config.RecentWindow) {
+			recent++
+		}
+	}
+	if recent > 0 {
+		return fmt.Sprintf(" Peers(%d) [yellow]%d recent[-] ", len(peers), recent)
+	}
+	return fmt.Sprintf(" Peers(%d) ", len(peers))
+}
+
 // formatASPath converte o caminho de ASNs para uma string formatada.
-func formatASPath(path []parser.AsPath) string {
+func formatASPath(segments []parser.PathSegment) string {
 	var formattedPath []string
-	count := 1
-
-	// Process consecutive repeated ASNs
-	for i := 0; i < len(path); i++ {
-		current := path[i].AsNumber
 
-		// Count repetitions
-		for i+1 < len(path) && path[i+1].AsNumber == current {
-			count++
-			i++
+	for _, segment := range segments {
+		// Sets and confederation segments are rendered as a single element
+		if segment.Type != parser.SegmentSequence {
+			formattedPath = append(formattedPath, tview.Escape(segment.String()))
+			continue
 		}
 
-		// Format the ASN with repetition count if needed
-		if count > 1 {
-			formattedPath = append(formattedPath, fmt.Sprintf("( %d x %d )", current, count))
-		} else {
-			formattedPath = append(formattedPath, fmt.Sprintf("%d", current))
+		// Process consecutive repeated ASNs
+		path := segment.ASNs
+		count := 1
+		for i := 0; i < len(path); i++ {
+			current := path[i].AsNumber
+
+			// Count repetitions
+			for i+1 < len(path) && path[i+1].AsNumber == current {
+				count++
+				i++
+			}
+
+			// Format the ASN with repetition count if needed
+			if count > 1 {
+				formattedPath = append(formattedPath, fmt.Sprintf("( %d x %d )", current, count))
+			} else {
+				formattedPath = append(formattedPath, fmt.Sprintf("%d", current))
+			}
+			count = 1
 		}
-		count = 1
 	}
 
 	// Format with line breaks if too long
@@ -51,20 +140,26 @@ func formatASPath(path []parser.AsPath) string {
 
 // Função para filtrar e atualizar lista
 func (tui *TUI) filterAndUpdatePeersList(searchTerm string) {
-	// Limpar lista atual
-	tui.PeersList.Clear()
-
 	// Filtrar peers
 	tui.filteredPeers = []parser.Peer{}
 	for _, peer := range tui.originalPeers {
-		if strings.Contains(strings.ToLower(peer.PeerName), strings.ToLower(searchTerm)) {
+		if peerMatches(&peer, searchTerm) {
 			tui.filteredPeers = append(tui.filteredPeers, peer)
 		}
 	}
+	tui.grouping.sort(tui.filteredPeers)
+
+	tui.refreshPeersList()
+}
+
+// refreshPeersList redesenha a lista de peers a partir de filteredPeers.
+func (tui *TUI) refreshPeersList() {
+	// Limpar lista atual
+	tui.PeersList.Clear()
 
 	// Atualizar lista na UI
 	for i, peer := range tui.filteredPeers {
-		tui.PeersList.AddItem(fmt.Sprintf("[%02d] %s", i+1, peer.PeerName), "", 0, func(index int) func() {
+		tui.PeersList.AddItem(tui.peerListLabel(i, &peer), "", 0, func(index int) func() {
 			return func() {
 				tui.CurrentPeer = index
 				tui.updateContent()
@@ -73,5 +168,101 @@ func (tui *TUI) filterAndUpdatePeersList(searchTerm string) {
 	}
 
 	// Atualizar título com quantidade
-	tui.PeersList.SetTitle(fmt.Sprintf(" Peers(%d) ", len(tui.filteredPeers)))
+	tui.PeersList.SetTitle(peersListTitle(tui.filteredPeers) + tui.resultSetLabel())
+}
+
+// peerMatches checks the search term against the peer name and metadata.
+// Terms may be qualified to search a single field: "cc:NL", "city:amsterdam",
+// "org:coloclue" or "as:8283".
+func peerMatches(peer *parser.Peer, searchTerm string) bool {
+	term := strings.ToLower(strings.TrimSpace(searchTerm))
+	if term == "" {
+		return true
+	}
+
+	info := peer.Info
+	asn := ""
+	if info.ASN != 0 {
+		asn = strconv.Itoa(info.ASN)
+	}
+
+	if field, value, ok := strings.Cut(term, ":"); ok {
+		switch field {
+		case "cc", "country":
+			return strings.EqualFold(info.Country, value)
+		case "city":
+			return strings.Contains(strings.ToLower(info.City), value)
+		case "org":
+			return strings.Contains(strings.ToLower(info.Organisation), value)
+		case "as", "asn":
+			return asn == strings.TrimPrefix(value, "as")
+		}
+	}
+
+	for _, candidate := range []string{peer.PeerName, info.Organisation, info.Country, info.City, asn} {
+		if strings.Contains(strings.ToLower(candidate), term) {
+			return true
+		}
+	}
+	return false
+}
+
+// peerGrouping define a ordem e o agrupamento da lista de peers.
+type peerGrouping int
+
+const (
+	groupByName peerGrouping = iota
+	groupByCountry
+	groupByOperator
+)
+
+// key returns the group label shown in front of the peer name.
+func (g peerGrouping) key(peer *parser.Peer) string {
+	switch g {
+	case groupByCountry:
+		if peer.Info.Country == "" {
+			return "??"
+		}
+		return peer.Info.Country
+	case groupByOperator:
+		if peer.Info.ASN == 0 {
+			return "AS?"
+		}
+		return fmt.Sprintf("AS%d", peer.Info.ASN)
+	default:
+		return ""
+	}
+}
+
+// sort orders the peers by group, keeping the looking glass order inside
+// each group.
+func (g peerGrouping) sort(peers []parser.Peer) {
+	if g == groupByName {
+		return
+	}
+	sort.SliceStable(peers, func(i, j int) bool {
+		return g.key(&peers[i]) < g.key(&peers[j])
+	})
+}
+
+// cycleGrouping alterna o agrupamento da lista entre nome, país e operador.
+func (tui *TUI) cycleGrouping() {
+	tui.grouping = (tui.grouping + 1) % 3
+
+	// Regrouping by name restores the looking glass order
+	names := make(map[string]bool, len(tui.filteredPeers))
+	for _, peer := range tui.filteredPeers {
+		names[peer.PeerName] = true
+	}
+	tui.filteredPeers = tui.filteredPeers[:0:0]
+	for _, peer := range tui.originalPeers {
+		if names[peer.PeerName] {
+			tui.filteredPeers = append(tui.filteredPeers, peer)
+		}
+	}
+	tui.grouping.sort(tui.filteredPeers)
+
+	tui.CurrentPeer = 0
+	tui.refreshPeersList()
+	tui.updateContent()
 }
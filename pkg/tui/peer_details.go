@@ -2,24 +2,64 @@ package tui
 
 import (
 	"fmt"
+	"net/netip"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/drksbr/lg2/pkg/analysis"
+	"github.com/drksbr/lg2/pkg/asrel"
+	"github.com/drksbr/lg2/pkg/community"
+	"github.com/drksbr/lg2/pkg/config"
+	"github.com/drksbr/lg2/pkg/irr"
 	"github.com/drksbr/lg2/pkg/parser"
+	"github.com/drksbr/lg2/pkg/report"
+	"github.com/drksbr/lg2/pkg/rpki"
+	"github.com/rivo/tview"
 )
 
-func buildPeerDetails(peer *parser.Peer) string {
+func buildPeerDetails(peer *parser.Peer, opts Options, consensus analysis.Consensus) string {
 	var details strings.Builder
 
 	// Build the header string with prefix and peer
-	details.WriteString(fmt.Sprintf("[::b]Info:[::-] %s / %s\n\n", peer.Prefix, peer.PeerName))
+	details.WriteString(fmt.Sprintf("[::b]Info:[::-] %s / %s\n", peer.Prefix, peer.PeerName))
+	details.WriteString(fmt.Sprintf("[::b]Peer:[::-] %s\n\n", formatPeerInfo(peer.Info)))
 
 	// Build the details string
-	details.WriteString(fmt.Sprintf("[::b]AS-PATH:[::-] %s\n\n", formatASPath(peer.AsPath)))
+	segments := peer.PathSegments()
+	details.WriteString(fmt.Sprintf("[::b]AS-PATH:[::-] %s\n\n", formatASPath(segments)))
+	details.WriteString(formatConsensusDiff(peer, consensus))
+
+	// Path length and origin according to the segment types
+	origin := "none"
+	if asn, ok := peer.OriginAS(); ok {
+		origin = fmt.Sprintf("AS%d", asn)
+	} else if len(segments) > 0 && segments[len(segments)-1].Type.IsSet() {
+		origin = "none (path ends in an AS_SET)"
+	}
+	details.WriteString(fmt.Sprintf("[::b]Path Length:[::-] %d / [::b]Origin AS:[::-] %s\n\n", peer.PathLength(), origin))
+	details.WriteString(formatPrepending(peer))
+	details.WriteString(formatCountryPath(peer, opts.LocalRegion))
+
+	// Origin validation reported by the looking glass and computed locally
+	details.WriteString(formatOriginValidation(peer, opts.VRPs))
+	details.WriteString(formatIRRValidation(peer, opts.IRR))
+	details.WriteString(formatConeViolations(peer, opts.IRR))
+	details.WriteString(formatRelationships(peer, opts.Relationships))
+	details.WriteString(formatASPAValidation(peer, opts.ASPAs))
 
 	// Append AS path details
 	details.WriteString("[::b]Sequential:[::-]\n")
-	for i, as := range peer.AsPath {
-		details.WriteString(fmt.Sprintf("     [%02d] |%s| [::b]AS%d[::-] (%s)\n", i+1, as.Country, as.AsNumber, as.AsName))
+	i := 0
+	for _, segment := range segments {
+		for _, as := range segment.ASNs {
+			i++
+			details.WriteString(fmt.Sprintf("     [%02d] |%s| [::b]AS%d[::-] (%s)", i, as.Country, as.AsNumber, as.AsName))
+			if segment.Type != parser.SegmentSequence {
+				details.WriteString(fmt.Sprintf(" [::d]%s[::-]", segment.Type))
+			}
+			details.WriteString("\n")
+		}
 	}
 	details.WriteString("\n")
 
@@ -32,7 +72,7 @@ func buildPeerDetails(peer *parser.Peer) string {
 			if i > 0 && i%4 == 0 {
 				details.WriteString("\n     ")
 			}
-			details.WriteString(community)
+			details.WriteString(formatCommunity(community, opts.Communities))
 		}
 		details.WriteString("\n\n")
 	}
@@ -45,6 +85,408 @@ func buildPeerDetails(peer *parser.Peer) string {
 	// Append Last Update
 	details.WriteString("[::b]Last Update:[::-] ")
 	details.WriteString(peer.LastUpdate)
+	now := time.Now()
+	if age, ok := peer.Age(now); ok {
+		details.WriteString(fmt.Sprintf(" (up %s)", parser.FormatAge(age)))
+		if peer.RecentlyChanged(now, config.RecentWindow) {
+			details.WriteString(" [yellow::b]recently changed[-::-]")
+		}
+	}
 
 	return details.String()
 }
+
+// formatPeerInfo resume a identidade do peer em uma linha.
+func formatPeerInfo(info parser.PeerInfo) string {
+	var parts []string
+	if info.Organisation != "" {
+		parts = append(parts, fmt.Sprintf("%s (%s)", info.Node, info.Organisation))
+	}
+	if info.ASN != 0 {
+		parts = append(parts, fmt.Sprintf("AS%d", info.ASN))
+	}
+	if info.Address.IsValid() {
+		parts = append(parts, info.Address.String())
+	}
+	if location := info.Location(); location != "" {
+		if flag := CountryToFlag(info.Country); flag != info.Country {
+			location += " " + flag
+		}
+		parts = append(parts, location)
+	}
+	if len(parts) == 0 {
+		return "unknown"
+	}
+	return strings.Join(parts, " / ")
+}
+
+// formatQueryWarnings lista os ajustes feitos no input da consulta.
+func formatQueryWarnings(query parser.Query) string {
+	var warnings strings.Builder
+	for _, warning := range query.Warnings {
+		warnings.WriteString(fmt.Sprintf("[yellow]Warning:[-] %s\n", tview.Escape(warning)))
+	}
+	if warnings.Len() > 0 {
+		warnings.WriteString("\n")
+	}
+	return warnings.String()
+}
+
+// formatPrepending mostra quem prependa no caminho e quantas vezes.
+func formatPrepending(peer *parser.Peer) string {
+	prepends := analysis.AnalyzePrepending(peer).Prepends
+	if len(prepends) == 0 {
+		return ""
+	}
+
+	parts := make([]string, len(prepends))
+	for i, prepend := range prepends {
+		parts[i] = fmt.Sprintf("AS%d +%d", prepend.ASN, prepend.Count)
+		if prepend.Origin {
+			parts[i] += " (origin)"
+		}
+	}
+	return fmt.Sprintf("[::b]Prepending:[::-] %s\n\n", strings.Join(parts, ", "))
+}
+
+// formatConsensusDiff mostra o caminho do peer comparado ao caminho mais
+// comum, quando diferem.
+func formatConsensusDiff(peer *parser.Peer, consensus analysis.Consensus) string {
+	diff := consensus.Diff(peer)
+	if len(consensus.Path) == 0 || diff.Same() {
+		return ""
+	}
+	label := "[::b]Consensus Diff:[::-]"
+	if !consensus.Follows(peer) {
+		label = "[yellow::b]Off Consensus:[-::-]"
+	}
+	return fmt.Sprintf("%s %s\n\n", label, colorPathDiff(diff))
+}
+
+// colorPathDiff pinta os ASNs acrescentados em amarelo e os ausentes em
+// vermelho riscado.
+func colorPathDiff(diff analysis.PathDiff) string {
+	parts := make([]string, len(diff))
+	for i, op := range diff {
+		switch op.Op {
+		case analysis.DiffAdded:
+			parts[i] = fmt.Sprintf("[yellow]+%d[-]", op.ASN)
+		case analysis.DiffMissing:
+			parts[i] = fmt.Sprintf("[red::s]-%d[-::-]", op.ASN)
+		default:
+			parts[i] = fmt.Sprintf("%d", op.ASN)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatCommunityBanner destaca, acima de tudo, quantos peers veem a rota
+// com comunidades de blackhole ou graceful shutdown.
+func formatCommunityBanner(peers []parser.Peer, dict *community.Dictionary) string {
+	type alert struct {
+		peers       int
+		communities []string
+	}
+	alerts := map[string]*alert{}
+	for i := range peers {
+		seen := map[string]bool{}
+		for _, match := range dict.Alerts(&peers[i]) {
+			kind := match.Entry.Kind
+			if alerts[kind] == nil {
+				alerts[kind] = &alert{}
+			}
+			if !seen[kind] {
+				seen[kind] = true
+				alerts[kind].peers++
+			}
+			if !slices.Contains(alerts[kind].communities, match.Community) {
+				alerts[kind].communities = append(alerts[kind].communities, match.Community)
+			}
+		}
+	}
+
+	var text strings.Builder
+	for _, kind := range []string{community.KindBlackhole, community.KindGracefulShutdown} {
+		if a := alerts[kind]; a != nil {
+			text.WriteString(fmt.Sprintf("[white:red:b] %s [-:-:-] [red::b]%d of %d peers see the route tagged %s[-::-]\n", strings.ToUpper(kind), a.peers, len(peers), strings.Join(a.communities, ", ")))
+		}
+	}
+	if text.Len() > 0 {
+		text.WriteString("\n")
+	}
+	return text.String()
+}
+
+// formatCommunity acrescenta o significado da comunidade, se conhecido.
+func formatCommunity(text string, dict *community.Dictionary) string {
+	entry, ok := dict.Lookup(text)
+	if !ok || entry.Description == "" {
+		return tview.Escape(text)
+	}
+	switch entry.Kind {
+	case community.KindBlackhole, community.KindGracefulShutdown:
+		return fmt.Sprintf("[red::b]%s (%s)[-::-]", tview.Escape(text), tview.Escape(entry.Description))
+	default:
+		return fmt.Sprintf("%s [::d](%s)[::-]", tview.Escape(text), tview.Escape(entry.Description))
+	}
+}
+
+// formatMOASBanner avisa quando os peers veem mais de uma origem para o
+// prefixo, com a contagem de peers por origem.
+func formatMOASBanner(query parser.Query, peers []parser.Peer, opts Options) string {
+	origins := analysis.Origins(peers)
+	if len(origins) < 2 {
+		return ""
+	}
+	return fmt.Sprintf("[yellow::b]MOAS:[-::-] %s\n\n", formatOrigins(query.RoutePrefix(peers), origins, opts))
+}
+
+// formatOrigins lista as origens e seus peers, destacando as inesperadas
+// para a política da rota.
+func formatOrigins(route netip.Prefix, origins []analysis.OriginCount, opts Options) string {
+	parts := make([]string, len(origins))
+	for i, origin := range origins {
+		name := "no origin (AS_SET)"
+		if origin.HasOrigin {
+			name = fmt.Sprintf("AS%d", origin.Origin)
+		}
+		parts[i] = fmt.Sprintf("%s (%d peers)", name, origin.Peers)
+		if opts.Policy.UnexpectedOrigin(route, origin.Origin, origin.HasOrigin) {
+			parts[i] = fmt.Sprintf("[red]%s hijack suspect[-]", parts[i])
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatCountryPath mostra os países que o caminho atravessa, do peer até a
+// origem, destacando os que estão fora da região local.
+func formatCountryPath(peer *parser.Peer, local map[string]bool) string {
+	geo := analysis.AnalyzeGeo(peer, local)
+	if len(geo.Countries) < 2 {
+		return ""
+	}
+
+	countries := make([]string, len(geo.Countries))
+	for i, country := range geo.Countries {
+		countries[i] = country
+		if geo.LeavesRegion && !local[country] {
+			countries[i] = "[yellow]" + country + "[-]"
+		}
+	}
+	text := fmt.Sprintf("[::b]Countries:[::-] %s", strings.Join(countries, " → "))
+	if len(geo.Trombones) > 0 {
+		text += " [yellow::b]trombone[-::-]"
+	}
+	return text + "\n\n"
+}
+
+// formatRouteWarnings lista os problemas encontrados no prefixo e na rota do peer.
+func formatRouteWarnings(query parser.Query, peers []parser.Peer, peer *parser.Peer, opts Options) string {
+	warnings := append(report.QueryWarnings(query, peers, opts), report.PeerWarnings(query, peer, opts)...)
+
+	var text strings.Builder
+	for _, warning := range warnings {
+		// Warnings about another peer belong to that peer's details
+		if warning.Peer != "" && warning.Peer != peer.PeerName {
+			continue
+		}
+		text.WriteString(fmt.Sprintf("[red::b]Warning:[-::-] %s\n", tview.Escape(warning.Message)))
+	}
+	if text.Len() > 0 {
+		text.WriteString("\n")
+	}
+	return text.String()
+}
+
+// formatOriginValidation mostra o veredito RPKI do looking glass ao lado do local.
+func formatOriginValidation(peer *parser.Peer, validator rpki.Validator) string {
+	var text strings.Builder
+	lgState := strings.TrimSpace(peer.OriginValidation)
+	if lgState == "" {
+		lgState = "n/a"
+	}
+	text.WriteString(fmt.Sprintf("[::b]Origin Validation:[::-] LG %s", tview.Escape(lgState)))
+
+	if validator != nil {
+		local := rpki.ValidatePeer(validator, peer)
+		text.WriteString(fmt.Sprintf(" / local %s", colorState(local.State)))
+		if !peer.Prefix.IsValid() {
+			text.WriteString(" [::d](route prefix not shown by the LG)[::-]")
+		} else {
+			text.WriteString(fmt.Sprintf(" for %s", peer.Prefix))
+		}
+		if rpki.Disagrees(local.State, peer.OriginValidation) {
+			text.WriteString(" [red::b]disagrees with LG[-::-]")
+		}
+		for _, vrp := range local.Covering {
+			text.WriteString(fmt.Sprintf("\n     VRP %s", vrp))
+		}
+		if source, ok := validator.(interface{ Status() string }); ok {
+			text.WriteString(fmt.Sprintf("\n     [::d]%s[::-]", tview.Escape(source.Status())))
+		}
+	}
+	text.WriteString("\n\n")
+	return text.String()
+}
+
+// formatIRRValidation mostra se existe route object para o prefixo e a origem
+// e em quais bancos IRR.
+func formatIRRValidation(peer *parser.Peer, db *irr.Database) string {
+	if db == nil {
+		return ""
+	}
+
+	var text strings.Builder
+	result := db.ValidatePeer(peer)
+	text.WriteString(fmt.Sprintf("[::b]IRR:[::-] %s", colorRouteState(result.State)))
+	if len(result.Sources) > 0 {
+		text.WriteString(fmt.Sprintf(" (%s)", strings.Join(result.Sources, ", ")))
+	}
+	if result.State == irr.RouteInvalid {
+		for _, obj := range result.Objects {
+			text.WriteString(fmt.Sprintf("\n     route %s AS%d (%s)", obj.Prefix, obj.Origin, tview.Escape(obj.Source)))
+		}
+	}
+	if result.State != irr.RouteValid {
+		for _, obj := range result.Covering {
+			text.WriteString(fmt.Sprintf("\n     covered by %s AS%d (%s)", obj.Prefix, obj.Origin, tview.Escape(obj.Source)))
+		}
+	}
+	text.WriteString("\n\n")
+	return text.String()
+}
+
+// formatConeViolations lista os hops que repassaram rotas de ASNs fora do
+// cone de clientes declarado, indício de route leak.
+func formatConeViolations(peer *parser.Peer, db *irr.Database) string {
+	violations := db.CheckCones(peer)
+	if len(violations) == 0 {
+		return ""
+	}
+
+	var text strings.Builder
+	text.WriteString("[::b]Customer Cones:[::-] [red::b]possible leak[-::-]")
+	for _, violation := range violations {
+		outside := make([]string, len(violation.Outside))
+		for i, asn := range violation.Outside {
+			outside[i] = fmt.Sprintf("AS%d", asn)
+		}
+		text.WriteString(fmt.Sprintf("\n     AS%d (%s) passed on %s", violation.ASN, tview.Escape(violation.Cone), strings.Join(outside, " ")))
+	}
+	text.WriteString("\n\n")
+	return text.String()
+}
+
+// formatRelationships anota cada enlace do caminho, da origem para cima, e
+// aponta quem quebrou o valley-free ou o OTC.
+func formatRelationships(peer *parser.Peer, rels *asrel.Set) string {
+	if rels == nil {
+		return ""
+	}
+	check := rels.CheckPeer(peer)
+	if len(check.Links) == 0 {
+		return ""
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("[::b]Relationships:[::-] AS%d", check.Links[0].From))
+	for _, link := range check.Links {
+		kind := link.Kind()
+		if link.Rel == asrel.Unknown {
+			kind = "[::d]?[::-]"
+		}
+		text.WriteString(fmt.Sprintf(" %s AS%d", kind, link.To))
+	}
+	if check.HasOTC {
+		text.WriteString(fmt.Sprintf(" [::d](OTC AS%d)[::-]", check.OTC))
+	}
+
+	for _, leak := range check.Leaks {
+		text.WriteString(fmt.Sprintf("\n     [red::b]Leak:[-::-] %s", report.LeakMessage(check, leak)))
+	}
+	text.WriteString("\n\n")
+	return text.String()
+}
+
+// colorRouteState colore um estado de validação IRR.
+func colorRouteState(state irr.RouteState) string {
+	switch state {
+	case irr.RouteValid:
+		return "[green]" + state.String() + "[-]"
+	case irr.RouteInvalid:
+		return "[red]" + state.String() + "[-]"
+	default:
+		return state.String()
+	}
+}
+
+// formatASPAValidation mostra o veredito ASPA do looking glass e a verificação
+// local salto a salto.
+func formatASPAValidation(peer *parser.Peer, aspas *rpki.ASPASet) string {
+	lgState := strings.TrimSpace(peer.AspaValidation)
+	if aspas == nil && lgState == "" {
+		return ""
+	}
+	if lgState == "" {
+		lgState = "n/a"
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("[::b]ASPA Validation:[::-] LG %s", tview.Escape(lgState)))
+	if aspas == nil {
+		text.WriteString("\n\n")
+		return text.String()
+	}
+
+	result := aspas.VerifyPeer(peer)
+	text.WriteString(fmt.Sprintf(" / local upstream %s, downstream %s", colorASPAState(result.Upstream), colorASPAState(result.Downstream)))
+	if lg := rpki.ParseASPAState(peer.AspaValidation); lg != rpki.ASPANotEvaluated && lg != result.Upstream && lg != result.Downstream {
+		text.WriteString(" [red::b]disagrees with LG[-::-]")
+	}
+	if result.HasASSet {
+		text.WriteString("\n     AS_SET in path: invalid by definition")
+	}
+
+	// Hops from the origin up to the neighbor
+	for _, hop := range result.Hops {
+		text.WriteString(fmt.Sprintf("\n     AS%d → AS%d  up: %s / down: %s", hop.Lower, hop.Upper, colorHopCheck(hop.Up), colorHopCheck(hop.Down)))
+	}
+	text.WriteString("\n\n")
+	return text.String()
+}
+
+// colorASPAState colore um estado de verificação ASPA.
+func colorASPAState(state rpki.ASPAState) string {
+	switch state {
+	case rpki.ASPAValid:
+		return "[green]" + state.String() + "[-]"
+	case rpki.ASPAInvalid:
+		return "[red]" + state.String() + "[-]"
+	default:
+		return state.String()
+	}
+}
+
+// colorHopCheck colore o resultado de um hop(x, y) ASPA.
+func colorHopCheck(check rpki.HopCheck) string {
+	switch check {
+	case rpki.HopProvider:
+		return "[green]" + check.String() + "[-]"
+	case rpki.HopNotProvider:
+		return "[red]" + check.String() + "[-]"
+	default:
+		return "[::d]" + check.String() + "[::-]"
+	}
+}
+
+// colorState colore um estado de validação RPKI.
+func colorState(state rpki.State) string {
+	switch state {
+	case rpki.StateValid:
+		return "[green]" + state.String() + "[-]"
+	case rpki.StateInvalid:
+		return "[red]" + state.String() + "[-]"
+	default:
+		return state.String()
+	}
+}
@@ -4,11 +4,12 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/drksbr/lg2/pkg/analysis"
 	"github.com/drksbr/lg2/pkg/fetch"
 	"github.com/drksbr/lg2/pkg/parser"
 )
 
-func (tui *TUI) GetDataFromAPI(queryString string) ([]parser.Peer, error) {
+func (tui *TUI) GetDataFromAPI(queryString string) ([]resultSet, error) {
 	done := make(chan bool)
 	tui.IsQuerying = true
 
@@ -35,43 +36,82 @@ func (tui *TUI) GetDataFromAPI(queryString string) ([]parser.Peer, error) {
 	}()
 
 	// Check if queryString is valid
-	network, err := parser.GetNetworkFromPrefix(queryString)
+	queries, err := parser.ResolveQueries(queryString)
 	if err != nil {
 		done <- true
 		return nil, err
 	}
 
-	// Check if network is valid
-	if network == nil {
-		done <- true
-		return nil, fmt.Errorf("invalid network")
+	// Query each resolved address as its own result set
+	sets := make([]resultSet, 0, len(queries))
+	failed := 0
+	for _, query := range queries {
+		peers, err := tui.fetchPeers(query)
+		if err != nil {
+			failed++
+		}
+		sets = append(sets, resultSet{query: query, peers: peers, err: err})
 	}
 
-	// Fetch data
-	data, err := fetch.GetLookingGlassData(queryString)
-	if err != nil {
-		done <- true
-		return nil, err
+	// Stop spinner and return
+	done <- true
+	if failed == len(sets) {
+		return nil, sets[0].err
 	}
+	return sets, nil
+}
+
+// resultSet holds the peers returned for one resolved query.
+type resultSet struct {
+	query parser.Query
+	peers []parser.Peer
+	err   error
+}
 
-	// Parse results
-	peers, err := parser.ParseHTML(data, network.String())
+// fetchPeers queries the looking glass for a single resolved query.
+func (tui *TUI) fetchPeers(query parser.Query) ([]parser.Peer, error) {
+	// Fetch and parse data
+	peers, err := fetch.QueryPeers(query)
 	if err != nil {
-		done <- true
 		return nil, err
 	}
 
-	// Check results
-	if len(peers) == 0 {
-		done <- true
-		return nil, fmt.Errorf("no peers found")
-	}
+	// Enrich peer metadata from the local RING node list
+	tui.opts.Nodes.EnrichPeers(peers)
 
-	// Stop spinner and return
-	done <- true
 	return peers, nil
 }
 
+// showResults replaces the current results with the new result sets.
+func (tui *TUI) showResults(sets []resultSet) {
+	tui.resultSets = sets
+	tui.selectResultSet(0)
+}
+
+// selectResultSet shows the peers of the given result set.
+func (tui *TUI) selectResultSet(index int) {
+	if index < 0 || index >= len(tui.resultSets) {
+		return
+	}
+	set := tui.resultSets[index]
+	tui.currentSet = index
+	tui.query = set.query
+	tui.originalPeers = set.peers
+	tui.consensus = analysis.FindConsensus(set.peers)
+	tui.CurrentPeer = 0
+	tui.filterAndUpdatePeersList("")
+	tui.updateContent()
+}
+
+// resultSetLabel identifies the current result set in the peers list title.
+func (tui *TUI) resultSetLabel() string {
+	if len(tui.resultSets) < 2 {
+		return ""
+	}
+	set := tui.resultSets[tui.currentSet]
+	return fmt.Sprintf("(%s %d/%d) ", set.query.Addr, tui.currentSet+1, len(tui.resultSets))
+}
+
 func (tui *TUI) updateTUIWithNewQuery(queryString string) {
 	// Verificar se já está em consulta
 	if tui.IsQuerying {
@@ -109,34 +149,17 @@ func (tui *TUI) updateTUIWithNewQuery(queryString string) {
 			done <- true
 		}()
 
-		newPeers, err := tui.GetDataFromAPI(queryString)
+		sets, err := tui.GetDataFromAPI(queryString)
 		if err != nil {
 			tui.App.QueueUpdateDraw(func() {
-				tui.Content.SetText(fmt.Sprintf("Error: %s", err),)
+				tui.Content.SetText(fmt.Sprintf("Error: %s", err))
 				tui.PeersList.SetTitle(" Peers(0) ")
 			})
 			return
 		}
 
 		tui.App.QueueUpdateDraw(func() {
-			tui.originalPeers = newPeers
-			tui.filteredPeers = newPeers
-			tui.PeersList.Clear()
-
-			for i, peer := range newPeers {
-				tui.PeersList.AddItem(fmt.Sprintf("[%02d] %s", i+1, peer.PeerName), "", 0, func(index int) func() {
-					return func() {
-						tui.CurrentPeer = index
-						tui.updateContent()
-					}
-				}(i))
-			}
-
-			tui.PeersList.SetTitle(fmt.Sprintf(" Peers(%d) ", len(newPeers)))
-			if len(newPeers) > 0 {
-				tui.CurrentPeer = 0
-				tui.updateContent()
-			}
+			tui.showResults(sets)
 
 			tui.SearchForm.Clear(true) // Limpar formulário de busca
 			// set focus on the peers list
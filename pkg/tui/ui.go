@@ -3,8 +3,11 @@ package tui
 import (
 	"fmt"
 
+	"github.com/drksbr/lg2/pkg/analysis"
 	"github.com/drksbr/lg2/pkg/config"
 	"github.com/drksbr/lg2/pkg/parser"
+	"github.com/drksbr/lg2/pkg/report"
+	"github.com/drksbr/lg2/pkg/rpki"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
@@ -33,10 +36,21 @@ type TUI struct {
 	CurrentPeer int
 
 	// Data
+	resultSets    []resultSet
+	currentSet    int
+	view          contentView
+	query         parser.Query
 	originalPeers []parser.Peer
+	consensus     analysis.Consensus // Consenso dos peers do conjunto atual
 	filteredPeers []parser.Peer
+	grouping      peerGrouping
+	opts          Options
 }
 
+// Options carries the datasets loaded by the CLI into the interface. It is
+// shared with the non-interactive report.
+type Options = report.Options
+
 var (
 	mwLogo = `  [::b]▒▒  ▒▓ ▒▒▒▒▓  ▓▒▒▒▒▒
   ▓  ▓ ▒ ▒ ▒   ▓▒    ▒ 
@@ -46,7 +60,7 @@ var (
 )
 
 // NewTUI configures and returns an instance of terminal user interface.
-func NewTUI(queryString string) *TUI {
+func NewTUI(queryString string, opts Options) *TUI {
 
 	// Blank Peer List
 	peers := []parser.Peer{}
@@ -65,6 +79,7 @@ func NewTUI(queryString string) *TUI {
 		IsSearching:   false,
 		IsQuerying:    false,
 		CurrentPeer:   0,
+		opts:          opts,
 	}
 
 	go func() {
@@ -78,7 +93,7 @@ func NewTUI(queryString string) *TUI {
 		}
 
 		// Make query to API
-		peers, err := tui.GetDataFromAPI(queryString)
+		sets, err := tui.GetDataFromAPI(queryString)
 		if err != nil {
 			tui.App.QueueUpdateDraw(func() {
 				tui.Content.SetText(fmt.Sprintf("Error: %s", err))
@@ -88,23 +103,19 @@ func NewTUI(queryString string) *TUI {
 		}
 
 		tui.App.QueueUpdateDraw(func() {
-			tui.originalPeers = peers
-			tui.filteredPeers = peers
-			tui.PeersList.SetTitle(fmt.Sprintf(" Peers(%d) ", len(peers)))
-			for i, peer := range peers {
-				tui.PeersList.AddItem(fmt.Sprintf("[%02d] %s", i+1, peer.PeerName), "", 0, func(index int) func() {
-					return func() {
-						tui.CurrentPeer = index
-						tui.updateContent()
-					}
-				}(i))
-			}
-			if len(peers) > 0 {
-				tui.updateContent()
-			}
+			tui.showResults(sets)
 		})
 	}()
 
+	// Re-validate the routes whenever a live VRP feed changes
+	if feed, ok := opts.VRPs.(rpki.Feed); ok {
+		go func() {
+			for range feed.Updates() {
+				tui.App.QueueUpdateDraw(tui.refreshValidation)
+			}
+		}()
+	}
+
 	// Configure Logo
 	tui.Logo.SetTextAlign(tview.AlignCenter).
 		SetDynamicColors(true).
@@ -138,7 +149,7 @@ func NewTUI(queryString string) *TUI {
 	tui.Shortcuts.SetBorderColor(tcell.ColorDefault)
 	tui.Shortcuts.SetTitleColor(tcell.ColorDefault)
 	tui.Shortcuts.SetTitle(" Shortcuts ").SetBorder(true)
-	tui.Shortcuts.SetText("Change ['Tab'] / Quit ['q']\nFind ['f'] / Query ['n']\nNav [←][→] / Select [↓][↑]")
+	tui.Shortcuts.SetText("Change ['Tab'] / Quit ['q']\nFind ['f'] / Query ['n']\nGroup ['g'] / Set ['s']\nDual-stack ['d'] / Analysis ['a']\nNav [←][→] / Select [↓][↑]")
 
 	// Criar Search Box
 	tui.SearchForm.SetBackgroundColor(tcell.ColorDefault)
@@ -194,7 +205,7 @@ func NewTUI(queryString string) *TUI {
 
 	// Configure Peers List
 	for i, peer := range peers {
-		tui.PeersList.AddItem(fmt.Sprintf("[%02d] %s", i+1, peer.PeerName), "", 0, func(index int) func() {
+		tui.PeersList.AddItem(tui.peerListLabel(i, &peer), "", 0, func(index int) func() {
 			return func() {
 				tui.CurrentPeer = index
 				tui.updateContent()
@@ -209,7 +220,7 @@ func NewTUI(queryString string) *TUI {
 	// Configure Grid Layout
 	tui.LeftPannel = tview.NewFlex().SetDirection(tview.FlexRow).
 		AddItem(tui.Logo, 7, 1, false).
-		AddItem(tui.Shortcuts, 5, 1, false).
+		AddItem(tui.Shortcuts, 7, 1, false).
 		AddItem(tui.PeersList, 0, 1, true)
 
 	tui.Grid = tview.NewGrid().SetRows(0).SetColumns(30, 0).
@@ -258,13 +269,37 @@ func (tui *TUI) SetupKeyboardShortcuts() {
 			tui.LeftPannel.RemoveItem(tui.SearchForm)
 			tui.LeftPannel.RemoveItem(tui.PeersList)
 			tui.LeftPannel.RemoveItem(tui.NewQueryForm)
-			tui.LeftPannel.AddItem(tui.Shortcuts, 5, 1, false)
+			tui.LeftPannel.AddItem(tui.Shortcuts, 7, 1, false)
 			tui.LeftPannel.AddItem(tui.PeersList, 0, 1, true)
 
 			tui.App.SetFocus(tui.PeersList)
 			return nil
 		}
 
+		// Cycle the grouping of the peers list (name, country, operator)
+		if (event.Rune() == 'g' || event.Rune() == 'G') && !tui.IsSearching {
+			tui.cycleGrouping()
+			return nil
+		}
+
+		// Switch between the result sets of a multi-address query
+		if (event.Rune() == 's' || event.Rune() == 'S') && !tui.IsSearching {
+			tui.selectResultSet((tui.currentSet + 1) % max(len(tui.resultSets), 1))
+			return nil
+		}
+
+		// Toggle the dual-stack comparison view
+		if (event.Rune() == 'd' || event.Rune() == 'D') && !tui.IsSearching {
+			tui.toggleView(viewDualStack)
+			return nil
+		}
+
+		// Toggle the query-wide analysis view
+		if (event.Rune() == 'a' || event.Rune() == 'A') && !tui.IsSearching {
+			tui.toggleView(viewAnalysis)
+			return nil
+		}
+
 		// Quit the application when 'q' or 'Q' and tui.IsSearching is false is pressed
 		if (event.Rune() == 'q' || event.Rune() == 'Q') && !tui.IsSearching {
 			tui.App.Stop()
@@ -283,18 +318,47 @@ func (tui *TUI) SetupKeyboardShortcuts() {
 
 // updateContent updates the details of the selected peer.
 func (tui *TUI) updateContent() {
+	switch tui.view {
+	case viewDualStack:
+		tui.Content.SetText(tui.buildDualStackView())
+		return
+	case viewAnalysis:
+		tui.Content.SetText(tui.buildAnalysisView())
+		return
+	}
+
 	if len(tui.filteredPeers) == 0 {
+		if len(tui.resultSets) > 0 && tui.resultSets[tui.currentSet].err != nil {
+			tui.Content.SetText(fmt.Sprintf("Error: %s", tui.resultSets[tui.currentSet].err))
+			return
+		}
 		tui.Content.SetText("Nenhum peer encontrado.")
 		return
 	}
 
 	peer := tui.filteredPeers[tui.CurrentPeer]
-	details := buildPeerDetails(&peer)
+	details := formatCommunityBanner(tui.originalPeers, tui.opts.Communities) + formatQueryWarnings(tui.query) + formatMOASBanner(tui.query, tui.originalPeers, tui.opts) + formatRouteWarnings(tui.query, tui.originalPeers, &peer, tui.opts) + buildPeerDetails(&peer, tui.opts, tui.consensus)
 
 	// Set the text of the content box
 	tui.Content.SetText(details)
 }
 
+// refreshValidation redraws the list and details after the VRP table changed.
+func (tui *TUI) refreshValidation() {
+	// The first sync may arrive before the results: keep the help or the
+	// loading message until then
+	if len(tui.resultSets) == 0 {
+		return
+	}
+	current := tui.CurrentPeer
+	tui.refreshPeersList()
+	if current < len(tui.filteredPeers) {
+		tui.CurrentPeer = current
+		tui.PeersList.SetCurrentItem(current)
+	}
+	tui.updateContent()
+}
+
 // CycleFocus alterna o foco entre os quadros de lista de peers e o conteúdo.
 func CycleFocus(app *tview.Application, list *tview.List, content *tview.TextView, grid *tview.Grid) {
 	focused := app.GetFocus()